@@ -0,0 +1,249 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codes
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aacfactory/fnc/sources"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+)
+
+const (
+	cacheDir      = ".fnc"
+	cacheFilename = "cache.json"
+)
+
+// cache records hashes of service sources and generated files, services whose hashes are not changed are skipped.
+type cache struct {
+	// Version is the version of fnc and forg, cache is invalid when they are changed or it is empty.
+	Version string `json:"version"`
+	// Project is the hash of go.mod and go.sum.
+	Project string `json:"project"`
+	// Services are keyed by dir of service.
+	Services map[string]cacheEntry `json:"services"`
+}
+
+type cacheEntry struct {
+	Name    string            `json:"name"`
+	Source  string            `json:"source"`
+	Outputs map[string]string `json:"outputs"`
+}
+
+// loadCache reads cache of project, an empty cache is returned when it is absent or broken.
+func loadCache(projectDir string) (c *cache) {
+	c = &cache{
+		Services: make(map[string]cacheEntry),
+	}
+	p, readErr := os.ReadFile(filepath.Join(projectDir, cacheDir, cacheFilename))
+	if readErr != nil {
+		return
+	}
+	v := &cache{}
+	if json.Unmarshal(p, v) != nil || v.Services == nil {
+		return
+	}
+	c = v
+	return
+}
+
+func (c *cache) save(projectDir string) (err error) {
+	p, encodeErr := json.MarshalIndent(c, "", "  ")
+	if encodeErr != nil {
+		err = fmt.Errorf("fnc: encode cache failed, %v", encodeErr)
+		return
+	}
+	dir := filepath.Join(projectDir, cacheDir)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		err = fmt.Errorf("fnc: save cache failed, %v", err)
+		return
+	}
+	if err = os.WriteFile(filepath.Join(dir, cacheFilename), p, 0644); err != nil {
+		err = fmt.Errorf("fnc: save cache failed, %v", err)
+		return
+	}
+	return
+}
+
+// stale returns dirs of services which need to be generated, full is true when all services need to be generated.
+func (c *cache) stale(current *cache) (services []string, full bool) {
+	if current.Version == "" || c.Version != current.Version || c.Project != current.Project {
+		full = true
+		return
+	}
+	services = make([]string, 0, 1)
+	for dir, entry := range current.Services {
+		cached, has := c.Services[dir]
+		if !has || cached.Name != entry.Name || cached.Source != entry.Source || !sameOutputs(cached.Outputs, entry.Outputs) {
+			services = append(services, dir)
+		}
+	}
+	sort.Strings(services)
+	if len(services) > 0 && len(services) == len(current.Services) {
+		full = true
+	}
+	return
+}
+
+// sameServices returns true when c and current have same services, modules/fns.go needs to be generated again when they are different.
+func (c *cache) sameServices(current *cache) bool {
+	if len(c.Services) != len(current.Services) {
+		return false
+	}
+	for dir, entry := range current.Services {
+		if cached, has := c.Services[dir]; !has || cached.Name != entry.Name {
+			return false
+		}
+	}
+	return true
+}
+
+// merge keeps entries of services which are not generated in this run, so they are still stale in the next run.
+func (c *cache) merge(cached *cache, generated []*sources.Service) {
+	for dir := range c.Services {
+		if containsService(generated, dir) {
+			continue
+		}
+		if entry, has := cached.Services[dir]; has && c.Version != "" && cached.Version == c.Version && cached.Project == c.Project {
+			c.Services[dir] = entry
+			continue
+		}
+		delete(c.Services, dir)
+	}
+}
+
+func sameOutputs(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, hash := range a {
+		if b[name] != hash {
+			return false
+		}
+	}
+	return true
+}
+
+// currentCache returns hashes of services in project, sources of a service include go files of packages which are imported by it.
+func currentCache(projectDir string, services []*sources.Service) (c *cache, err error) {
+	c = &cache{
+		Version:  cacheVersion(),
+		Services: make(map[string]cacheEntry),
+	}
+	mods := make(map[string][]byte)
+	for _, name := range []string{"go.mod", "go.sum"} {
+		if p, readErr := os.ReadFile(filepath.Join(projectDir, name)); readErr == nil {
+			mods[name] = p
+		}
+	}
+	c.Project = hashFiles(mods)
+	deps := make(map[string]map[string][]byte)
+	for _, service := range services {
+		files, filesErr := serviceFiles(projectDir, service, services)
+		if filesErr != nil {
+			err = fmt.Errorf("fnc: read files of %s failed, %v", service.Dir, filesErr)
+			return
+		}
+		sources := make(map[string][]byte)
+		outputs := make(map[string]string)
+		for name, p := range files {
+			if isGenerated(p) {
+				outputs[name] = hashFiles(map[string][]byte{name: p})
+				continue
+			}
+			sources[name] = p
+		}
+		for _, dep := range service.Deps {
+			files, has := deps[dep]
+			if !has {
+				files = make(map[string][]byte)
+				if err = readFiles(filepath.Join(projectDir, filepath.FromSlash(dep)), dep, files); err != nil {
+					return
+				}
+				deps[dep] = files
+			}
+			for name, p := range files {
+				if filepath.Ext(name) == ".go" && !isGenerated(p) {
+					sources[name] = p
+				}
+			}
+		}
+		c.Services[service.Dir] = cacheEntry{
+			Name:    service.Name,
+			Source:  hashFiles(sources),
+			Outputs: outputs,
+		}
+	}
+	return
+}
+
+// cacheVersion returns versions of fnc and forg in build info, it is empty when the build can not be versioned.
+func cacheVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	return buildVersion(info)
+}
+
+// buildVersion returns versions of main module and forg, the vcs revision is used for devel builds.
+// it is empty for devel builds without revision or with modified sources, and for forg which is replaced by a dir, so the cache always misses.
+func buildVersion(info *debug.BuildInfo) (version string) {
+	version = info.Main.Version
+	if version == "" || version == "(devel)" {
+		revision, modified := "", false
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				revision = setting.Value
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+		if revision == "" || modified {
+			return ""
+		}
+		version = "(devel)@" + revision
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != "github.com/aacfactory/forg" {
+			continue
+		}
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		if dep.Version == "" {
+			return ""
+		}
+		version = version + "+forg@" + dep.Version
+		break
+	}
+	return
+}
+
+func cleanCache(projectDir string) (err error) {
+	filename := filepath.Join(projectDir, cacheDir, cacheFilename)
+	if err = os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		err = fmt.Errorf("fnc: remove %s failed, %v", filename, err)
+		return
+	}
+	err = nil
+	return
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codes
+
+import (
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestCacheStale(t *testing.T) {
+	entries := func(items ...string) map[string]cacheEntry {
+		services := make(map[string]cacheEntry)
+		for _, item := range items {
+			// dir=source/output
+			dir, hashes, _ := strings.Cut(item, "=")
+			source, output, _ := strings.Cut(hashes, "/")
+			services[dir] = cacheEntry{Name: dir, Source: source, Outputs: map[string]string{dir + "/fns.go": output}}
+		}
+		return services
+	}
+	cached := &cache{Version: "v1.0.0", Project: "p", Services: entries("users=1/1", "orders=2/2", "items=3/3")}
+	cases := []struct {
+		name     string
+		current  *cache
+		services string
+		full     bool
+	}{
+		{name: "unchanged", current: &cache{Version: "v1.0.0", Project: "p", Services: entries("users=1/1", "orders=2/2", "items=3/3")}},
+		{name: "source", current: &cache{Version: "v1.0.0", Project: "p", Services: entries("users=1/1", "orders=x/2", "items=3/3")}, services: "orders"},
+		{name: "output", current: &cache{Version: "v1.0.0", Project: "p", Services: entries("users=1/x", "orders=2/2", "items=3/3")}, services: "users"},
+		{name: "new service", current: &cache{Version: "v1.0.0", Project: "p", Services: entries("users=1/1", "orders=2/2", "items=3/3", "carts=4/4")}, services: "carts"},
+		{name: "all services", current: &cache{Version: "v1.0.0", Project: "p", Services: entries("users=x/1", "orders=x/2", "items=x/3")}, services: "items,orders,users", full: true},
+		{name: "project", current: &cache{Version: "v1.0.0", Project: "x", Services: entries("users=1/1", "orders=2/2", "items=3/3")}, full: true},
+		{name: "version", current: &cache{Version: "v1.0.1", Project: "p", Services: entries("users=1/1", "orders=2/2", "items=3/3")}, full: true},
+		{name: "no version", current: &cache{Version: "", Project: "p", Services: entries("users=1/1", "orders=2/2", "items=3/3")}, full: true},
+	}
+	for _, c := range cases {
+		services, full := cached.stale(c.current)
+		if got := strings.Join(services, ","); got != c.services || full != c.full {
+			t.Errorf("%s: got %s (full %v), want %s (full %v)", c.name, got, full, c.services, c.full)
+		}
+	}
+	if _, full := (&cache{Project: "p"}).stale(&cache{Project: "p"}); !full {
+		t.Errorf("cache without version must miss")
+	}
+}
+
+func TestBuildVersion(t *testing.T) {
+	forg := func(version string, replace *debug.Module) []*debug.Module {
+		return []*debug.Module{{Path: "github.com/aacfactory/forg", Version: version, Replace: replace}}
+	}
+	vcs := func(revision string, modified string) []debug.BuildSetting {
+		return []debug.BuildSetting{{Key: "vcs.revision", Value: revision}, {Key: "vcs.modified", Value: modified}}
+	}
+	cases := []struct {
+		name    string
+		info    *debug.BuildInfo
+		version string
+	}{
+		{name: "release", info: &debug.BuildInfo{Main: debug.Module{Version: "v1.2.0"}, Deps: forg("v1.0.0", nil)}, version: "v1.2.0+forg@v1.0.0"},
+		{name: "devel", info: &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}, Deps: forg("v1.0.0", nil), Settings: vcs("abc", "false")}, version: "(devel)@abc+forg@v1.0.0"},
+		{name: "devel with modified sources", info: &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}, Deps: forg("v1.0.0", nil), Settings: vcs("abc", "true")}},
+		{name: "devel without vcs", info: &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}, Deps: forg("v1.0.0", nil)}},
+		{name: "forg is replaced by module", info: &debug.BuildInfo{Main: debug.Module{Version: "v1.2.0"}, Deps: forg("v1.0.0", &debug.Module{Path: "example.com/forg", Version: "v1.0.1"})}, version: "v1.2.0+forg@v1.0.1"},
+		{name: "forg is replaced by dir", info: &debug.BuildInfo{Main: debug.Module{Version: "v1.2.0"}, Deps: forg("v1.0.0", &debug.Module{Path: "../forg"})}},
+	}
+	for _, c := range cases {
+		if got := buildVersion(c.info); got != c.version {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.version)
+		}
+	}
+}
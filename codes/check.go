@@ -0,0 +1,90 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codes
+
+import (
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// check generates codes into a scratch of project, and fails when files of project are different from generated.
+func check(ctx *cli.Context, projectDir string, work string, showDiff bool, rep *reporter) (err error) {
+	changes, previewErr := preview(ctx, projectDir, work, rep)
+	if previewErr != nil {
+		err = errors.Warning("fnc: codes check failed").WithCause(previewErr)
+		return
+	}
+	if len(changes) == 0 {
+		rep.message("fnc: generated codes are up to date")
+		return
+	}
+	for _, c := range changes {
+		rep.message("fnc: %s is out of date (%s)", c.Filename, c.Kind)
+	}
+	if showDiff && !rep.json {
+		for _, c := range changes {
+			fmt.Print(unifiedDiff(c.Filename, c.Old, c.New))
+		}
+	}
+	err = cli.Exit(fmt.Sprintf("fnc: %d generated files are out of date, please run `fnc codes`", len(changes)), 1)
+	return
+}
+
+// preview generates codes into a scratch of project and returns changes, the project is not touched.
+func preview(ctx *cli.Context, projectDir string, work string, rep *reporter) (changes []change, err error) {
+	s, stale, scratchErr := newPreviewScratch(projectDir)
+	if scratchErr != nil {
+		err = scratchErr
+		return
+	}
+	defer s.Close()
+	project, loadErr := load(s.dir, work)
+	if loadErr != nil {
+		err = loadErr
+		return
+	}
+	if err = run(ctx, project, rep); err != nil {
+		return
+	}
+	if err = removeFiles(s.dir, stale); err != nil {
+		return
+	}
+	changes, err = s.changes()
+	return
+}
+
+// newPreviewScratch copies project into a scratch, and returns stale generated files of project.
+// stale files are found in project before coding, because coding generates modules/fns.go again in scratch.
+func newPreviewScratch(projectDir string) (s *scratch, stale []string, err error) {
+	services, servicesErr := loadServices(projectDir)
+	if servicesErr != nil {
+		err = servicesErr
+		return
+	}
+	stale, err = staleFiles(projectDir, services, loadCache(projectDir))
+	if err != nil {
+		return
+	}
+	dirs, dirsErr := packageDirs(projectDir, services)
+	if dirsErr != nil {
+		err = dirsErr
+		return
+	}
+	s, err = newScratch(projectDir, dirs)
+	return
+}
@@ -0,0 +1,220 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codes
+
+import (
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/fnc/sources"
+	"github.com/urfave/cli/v2"
+	"go/parser"
+	"go/token"
+	"golang.org/x/mod/modfile"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var cleanCommand = &cli.Command{
+	Name:        "clean",
+	Usage:       "fnc codes clean {project path}",
+	Description: "remove all generated files of project",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:     "dry-run",
+			Usage:    "list generated files only, they are not removed",
+			Required: false,
+		},
+	},
+	Action: func(ctx *cli.Context) (err error) {
+		projectDir, dirErr := projectDirOf(ctx)
+		if dirErr != nil {
+			err = errors.Warning("fnc: clean codes failed").WithCause(dirErr)
+			return
+		}
+		names, namesErr := generatedFiles(projectDir)
+		if namesErr != nil {
+			err = errors.Warning("fnc: clean codes failed").WithCause(namesErr)
+			return
+		}
+		if len(names) == 0 {
+			fmt.Println("fnc: no generated file was found")
+			return
+		}
+		dryRun := ctx.Bool("dry-run")
+		for _, name := range names {
+			if dryRun {
+				fmt.Println(fmt.Sprintf("fnc: %s would be removed", name))
+			} else {
+				fmt.Println(fmt.Sprintf("fnc: %s is removed", name))
+			}
+		}
+		if dryRun {
+			return
+		}
+		if err = removeFiles(projectDir, names); err != nil {
+			err = errors.Warning("fnc: clean codes failed").WithCause(err)
+			return
+		}
+		fmt.Println("fnc: generated files have been removed, please run `fnc codes` to generate them again")
+		return
+	},
+}
+
+// generatedFiles returns go files which carry the generated header, paths are slash separated and relative to project dir.
+func generatedFiles(projectDir string) (names []string, err error) {
+	names = make([]string, 0, 1)
+	err = filepath.WalkDir(projectDir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() {
+			if path != projectDir && (skipDir(d.Name()) || d.Name() == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || filepath.Ext(path) != ".go" {
+			return nil
+		}
+		p, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return readErr
+		}
+		if !isGenerated(p) {
+			return nil
+		}
+		rel, relErr := filepath.Rel(projectDir, path)
+		if relErr != nil {
+			return relErr
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("fnc: find generated files failed, %v", err)
+		return
+	}
+	sort.Strings(names)
+	return
+}
+
+// staleFiles returns generated files which are directly in dirs of former services, they are not services anymore.
+// former services are services in cache and services imported by modules/fns.go, modules/fns.go is generated again by coding.
+func staleFiles(projectDir string, services []*sources.Service, cached *cache) (names []string, err error) {
+	former, formerErr := formerServices(projectDir, cached)
+	if formerErr != nil {
+		err = formerErr
+		return
+	}
+	names = make([]string, 0, 1)
+	for _, dir := range former {
+		if containsService(services, dir) {
+			continue
+		}
+		files := make(map[string][]byte)
+		if err = readFiles(filepath.Join(projectDir, filepath.FromSlash(dir)), dir, files); err != nil {
+			return
+		}
+		for name, p := range files {
+			if filepath.Ext(name) == ".go" && isGenerated(p) {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return
+}
+
+// formerServices returns dirs of services in cache and services imported by modules/fns.go, they are slash separated and relative to project dir.
+func formerServices(projectDir string, cached *cache) (dirs []string, err error) {
+	found := make(map[string]struct{})
+	for dir := range cached.Services {
+		found[dir] = struct{}{}
+	}
+	p, readErr := os.ReadFile(filepath.Join(projectDir, filepath.FromSlash(modulesFilename)))
+	if readErr == nil && isGenerated(p) {
+		mod, modErr := os.ReadFile(filepath.Join(projectDir, "go.mod"))
+		if modErr != nil {
+			err = fmt.Errorf("fnc: read go.mod failed, %v", modErr)
+			return
+		}
+		modPath := modfile.ModulePath(mod)
+		file, parseErr := parser.ParseFile(token.NewFileSet(), modulesFilename, p, parser.ImportsOnly)
+		if parseErr != nil {
+			err = fmt.Errorf("fnc: parse %s failed, %v", modulesFilename, parseErr)
+			return
+		}
+		for _, spec := range file.Imports {
+			importPath, _ := strconv.Unquote(spec.Path.Value)
+			if modPath != "" && strings.HasPrefix(importPath, modPath+"/modules/") {
+				found[strings.TrimPrefix(importPath, modPath+"/")] = struct{}{}
+			}
+		}
+	}
+	dirs = make([]string, 0, len(found))
+	for dir := range found {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return
+}
+
+// removeStaleFiles removes stale generated files, rep is optional.
+func removeStaleFiles(projectDir string, services []*sources.Service, cached *cache, rep *reporter) (err error) {
+	names, namesErr := staleFiles(projectDir, services, cached)
+	if namesErr != nil {
+		err = namesErr
+		return
+	}
+	if len(names) == 0 {
+		return
+	}
+	for _, name := range names {
+		if rep != nil {
+			rep.message("fnc: %s is removed, its service was not found", name)
+		}
+	}
+	err = removeFiles(projectDir, names)
+	return
+}
+
+// removeFiles removes files, and their parent dirs which become empty.
+func removeFiles(projectDir string, names []string) (err error) {
+	for _, name := range names {
+		filename := filepath.Join(projectDir, filepath.FromSlash(name))
+		if err = os.Remove(filename); err != nil && !os.IsNotExist(err) {
+			err = fmt.Errorf("fnc: remove %s failed, %v", name, err)
+			return
+		}
+		err = nil
+		root := filepath.Clean(projectDir)
+		for dir := filepath.Dir(filename); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+			entries, readErr := os.ReadDir(dir)
+			if readErr != nil || len(entries) > 0 {
+				break
+			}
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+	return
+}
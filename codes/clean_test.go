@@ -0,0 +1,150 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testMod       = "module example.com/project\n\ngo 1.20\n"
+	testGenerated = "// NOTE: this file has been automatically generated, DON'T EDIT IT!!!\n\npackage %s\n"
+)
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func generatedFile(pkg string) string {
+	return strings.Replace(testGenerated, "%s", pkg, 1)
+}
+
+func modulesFile(services ...string) string {
+	imports := make([]string, 0, len(services))
+	for _, service := range services {
+		imports = append(imports, "\t\"example.com/project/modules/"+service+"\"\n")
+	}
+	return generatedFile("modules") + "\nimport (\n" + strings.Join(imports, "") + ")\n"
+}
+
+func TestStaleFiles(t *testing.T) {
+	cases := []struct {
+		name   string
+		files  map[string]string
+		cached []string
+		stale  string
+	}{
+		{
+			name: "no former service",
+			files: map[string]string{
+				"modules/fns.go":       modulesFile("users"),
+				"modules/users/fns.go": generatedFile("users"),
+			},
+		},
+		{
+			name: "imported by modules",
+			files: map[string]string{
+				"modules/fns.go":        modulesFile("users", "orders"),
+				"modules/users/fns.go":  generatedFile("users"),
+				"modules/orders/fns.go": generatedFile("orders"),
+				"modules/orders/row.go": "package orders\n",
+			},
+			stale: "modules/orders/fns.go",
+		},
+		{
+			name: "in cache",
+			files: map[string]string{
+				"modules/users/fns.go":        generatedFile("users"),
+				"modules/orders/fns.go":       generatedFile("orders"),
+				"modules/orders/items/fns.go": generatedFile("items"),
+			},
+			cached: []string{"modules/users", "modules/orders"},
+			stale:  "modules/orders/fns.go",
+		},
+		{
+			name: "modules is not generated",
+			files: map[string]string{
+				"modules/fns.go":        "package modules\n\nimport _ \"example.com/project/modules/orders\"\n",
+				"modules/orders/fns.go": generatedFile("orders"),
+			},
+		},
+	}
+	for _, c := range cases {
+		dir := t.TempDir()
+		c.files["go.mod"] = testMod
+		c.files["modules/users/doc.go"] = "// Package users\n// @service users\npackage users\n"
+		writeTestFiles(t, dir, c.files)
+		services, err := loadServices(dir)
+		if err != nil {
+			t.Fatal(c.name, err)
+		}
+		cached := loadCache(dir)
+		for _, item := range c.cached {
+			cached.Services[item] = cacheEntry{}
+		}
+		names, err := staleFiles(dir, services, cached)
+		if err != nil {
+			t.Fatal(c.name, err)
+		}
+		if got := strings.Join(names, ","); got != c.stale {
+			t.Errorf("%s: got stale files %s, want %s", c.name, got, c.stale)
+		}
+	}
+}
+
+func TestPreviewDeletedService(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"go.mod":                testMod,
+		"modules/fns.go":        modulesFile("users", "orders"),
+		"modules/users/doc.go":  "// Package users\n// @service users\npackage users\n",
+		"modules/users/fns.go":  generatedFile("users"),
+		"modules/orders/fns.go": generatedFile("orders"),
+	})
+	s, stale, err := newPreviewScratch(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	// coding generates modules/fns.go again, the deleted service is not imported anymore
+	writeTestFiles(t, s.dir, map[string]string{"modules/fns.go": modulesFile("users")})
+	if err = removeFiles(s.dir, stale); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := s.changes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, 0, len(changes))
+	for _, c := range changes {
+		got = append(got, c.Filename+" "+c.Kind)
+	}
+	if want := "modules/fns.go modified,modules/orders/fns.go deleted"; strings.Join(got, ",") != want {
+		t.Errorf("got changes %s, want %s", strings.Join(got, ","), want)
+	}
+}
@@ -17,14 +17,22 @@
 package codes
 
 import (
+	"context"
 	"fmt"
 	"github.com/aacfactory/errors"
 	"github.com/aacfactory/forg"
 	"github.com/urfave/cli/v2"
+	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 )
 
+// abortTimeout is the max duration to wait units to stop when codes process is aborted.
+const abortTimeout = 3 * time.Second
+
 var Command = &cli.Command{
 	Name: "codes",
 	Flags: []cli.Flag{
@@ -42,58 +50,200 @@ var Command = &cli.Command{
 			EnvVars:   []string{"FNC_WORK"},
 			TakesFile: false,
 		},
+		&cli.BoolFlag{
+			Name:     "check",
+			Usage:    "generate codes into a temp copy of go.mod, modules and packages imported by services, and fail when generated files are out of date, project is not touched",
+			Required: false,
+		},
+		&cli.BoolFlag{
+			Name:     "diff",
+			Usage:    "print unified diff of out of date files, used with --check",
+			Required: false,
+		},
+		&cli.BoolFlag{
+			Name:     "dry-run",
+			Usage:    "generate codes into a temp copy of go.mod, modules and packages imported by services, and print what would be changed, project is not touched",
+			Required: false,
+		},
+		&cli.BoolFlag{
+			Name:     "no-cache",
+			Usage:    "generate all services and ignore cache of last run",
+			Required: false,
+		},
+		&cli.StringSliceFlag{
+			Name:     "service",
+			Aliases:  []string{"s"},
+			Usage:    "generate the service only, name or dir of service, repeatable",
+			Required: false,
+		},
+		&cli.StringSliceFlag{
+			Name:     "exclude",
+			Usage:    "skip services whose name or dir matches the glob, repeatable",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "output",
+			Aliases:  []string{"o"},
+			Value:    textOutput,
+			Usage:    "output format, text or json, json prints one event per line",
+			Required: false,
+		},
+		&cli.IntFlag{
+			Name:     "jobs",
+			Aliases:  []string{"j"},
+			Value:    1,
+			Usage:    "max scratches used at the same time when a part of services are generated, a full generation runs in place",
+			Required: false,
+		},
+		&cli.DurationFlag{
+			Name:     "timeout",
+			Usage:    "abort generation when it takes longer than timeout, e.g. 2m, it is ignored by --watch",
+			Required: false,
+		},
+		&cli.BoolFlag{
+			Name:     "watch",
+			Usage:    "watch go files of services and packages imported by them, and generate affected services when they are changed",
+			Required: false,
+		},
 	},
 	Aliases:     nil,
 	Usage:       "fnc codes {project path}",
 	Description: "scan fns project and generate fn codes",
 	ArgsUsage:   "",
 	Category:    "",
+	Subcommands: []*cli.Command{
+		cleanCommand,
+		{
+			Name:        "clean-cache",
+			Usage:       "fnc codes clean-cache {project path}",
+			Description: "remove cache of codes, the next run generates all services",
+			Action: func(ctx *cli.Context) (err error) {
+				projectDir, dirErr := projectDirOf(ctx)
+				if dirErr != nil {
+					err = errors.Warning("fnc: clean cache failed").WithCause(dirErr)
+					return
+				}
+				if err = cleanCache(projectDir); err != nil {
+					err = errors.Warning("fnc: clean cache failed").WithCause(err)
+					return
+				}
+				fmt.Println("fnc: cache has been removed")
+				return
+			},
+		},
+	},
 	Action: func(ctx *cli.Context) (err error) {
-		debug := ctx.Bool("debug")
-		projectDir := strings.TrimSpace(ctx.Args().First())
-		if projectDir == "" {
-			projectDir = "."
+		rep, repErr := newReporter(ctx.Bool("debug"), ctx.String("output"))
+		if repErr != nil {
+			err = errors.Warning("fnc: codes failed").WithCause(repErr)
+			return
 		}
-		if !filepath.IsAbs(projectDir) {
-			projectDir, err = filepath.Abs(projectDir)
-			if err != nil {
-				err = errors.Warning("fnc: codes failed").WithCause(err).WithMeta("dir", projectDir)
-				return
-			}
+		defer func() {
+			err = rep.finish(err)
+		}()
+		if ctx.Int("jobs") < 1 {
+			err = errors.Warning("fnc: codes failed").WithCause(errors.Warning("jobs is invalid")).WithMeta("jobs", ctx.String("jobs"))
+			return
+		}
+		// abort generation by signals or timeout
+		var cancel context.CancelFunc
+		ctx.Context, cancel = signal.NotifyContext(ctx.Context, os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+		if timeout := ctx.Duration("timeout"); timeout > 0 && !ctx.Bool("watch") {
+			var timeoutCancel context.CancelFunc
+			ctx.Context, timeoutCancel = context.WithTimeout(ctx.Context, timeout)
+			defer timeoutCancel()
+		}
+		projectDir, dirErr := projectDirOf(ctx)
+		if dirErr != nil {
+			err = errors.Warning("fnc: codes failed").WithCause(dirErr)
+			return
 		}
-		projectDir = filepath.ToSlash(projectDir)
 		work := ctx.String("work")
-		var project *forg.Project
-		if work != "" {
-			project, err = forg.Load(projectDir, forg.WithWorkspace(work))
-		} else {
-			project, err = forg.Load(projectDir)
+		if ctx.Bool("check") {
+			err = check(ctx, projectDir, work, ctx.Bool("diff"), rep)
+			return
 		}
-		if err != nil {
-			err = errors.Warning("fnc: codes failed").WithCause(err)
+		if ctx.Bool("dry-run") {
+			err = dryRun(ctx, projectDir, work, rep)
 			return
 		}
-		process, codingErr := project.Coding(ctx.Context)
-		if codingErr != nil {
-			err = errors.Warning("fnc: codes failed").WithCause(codingErr)
+		opt := generateOptions{
+			Work:     work,
+			Reporter: rep,
+			NoCache:  ctx.Bool("no-cache"),
+			Jobs:     ctx.Int("jobs"),
+			Services: ctx.StringSlice("service"),
+			Excludes: ctx.StringSlice("exclude"),
+		}
+		if ctx.Bool("watch") {
+			if runErr := generate(ctx, projectDir, opt); runErr != nil {
+				rep.message("%v", rep.failures(runErr))
+			}
+			opt.NoCache = false
+			err = watch(ctx, projectDir, opt)
 			return
 		}
-		results := process.Start(ctx.Context)
-		for {
-			result, ok := <-results
+		err = generate(ctx, projectDir, opt)
+		return
+	},
+}
+
+// projectDirOf returns the absolute project dir in args, default is current dir.
+func projectDirOf(ctx *cli.Context) (projectDir string, err error) {
+	projectDir = strings.TrimSpace(ctx.Args().First())
+	if projectDir == "" {
+		projectDir = "."
+	}
+	if !filepath.IsAbs(projectDir) {
+		projectDir, err = filepath.Abs(projectDir)
+		if err != nil {
+			return
+		}
+	}
+	projectDir = filepath.ToSlash(projectDir)
+	return
+}
+
+func load(projectDir string, work string) (project *forg.Project, err error) {
+	if work != "" {
+		project, err = forg.Load(projectDir, forg.WithWorkspace(work))
+	} else {
+		project, err = forg.Load(projectDir)
+	}
+	return
+}
+
+func run(ctx *cli.Context, project *forg.Project, rep *reporter) (err error) {
+	process, codingErr := project.Coding(ctx.Context)
+	if codingErr != nil {
+		err = errors.Warning("fnc: codes failed").WithCause(codingErr)
+		return
+	}
+	results := process.Start(ctx.Context)
+	sum := &summary{}
+	done := ctx.Context.Done()
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			_ = process.Abort(abortTimeout)
+			err = errors.Warning("fnc: codes aborted").WithCause(ctx.Context.Err())
+			return
+		case result, ok := <-results:
 			if !ok {
-				if debug {
-					fmt.Println("fnc: codes finished")
-				}
+				rep.debugf("fnc: codes finished")
+				finished = true
 				break
 			}
-			if debug {
-				fmt.Println(result, "->", fmt.Sprintf("[%d/%d]", result.UnitNo, result.UnitNum), result.Data)
-			}
+			rep.unit(int64(result.UnitNo), int64(result.UnitNum), result.String(), result.Data, result.Error)
 			if result.Error != nil {
-				fmt.Println(fmt.Sprintf("%+v", result.Error))
+				sum.add(result.String(), result.Error)
 			}
 		}
+	}
+	if sum.failed() {
+		err = errors.Warning("fnc: codes failed").WithCause(sum.err())
 		return
-	},
+	}
+	return
 }
@@ -0,0 +1,222 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codes
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"strings"
+)
+
+const (
+	diffContext = 3
+	// diffLimit is the max cells of lcs table, larger changes are shown as whole replacements.
+	diffLimit = 4 * 1024 * 1024
+)
+
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// unifiedDiff returns the unified diff of src and dst, it is empty when they are equal.
+func unifiedDiff(name string, src []byte, dst []byte) string {
+	a, b := splitLines(src), splitLines(dst)
+	lines := diffLines(a, b)
+	changed := false
+	for _, line := range lines {
+		if line.op != ' ' {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+	buf := strings.Builder{}
+	oldName, newName := "a/"+name, "b/"+name
+	if len(src) == 0 {
+		oldName = "/dev/null"
+	}
+	if len(dst) == 0 {
+		newName = "/dev/null"
+	}
+	buf.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", oldName, newName))
+	// hunks
+	i := 0
+	for i < len(lines) {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(lines) {
+			if lines[end].op != ' ' {
+				end++
+				continue
+			}
+			// stop when the next change is far away
+			next := end
+			for next < len(lines) && lines[next].op == ' ' {
+				next++
+			}
+			if next == len(lines) || next-end > 2*diffContext {
+				end += diffContext
+				if end > len(lines) {
+					end = len(lines)
+				}
+				break
+			}
+			end = next
+		}
+		oldStart, newStart := 1, 1
+		for _, line := range lines[:start] {
+			if line.op != '+' {
+				oldStart++
+			}
+			if line.op != '-' {
+				newStart++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, line := range lines[start:end] {
+			if line.op != '+' {
+				oldCount++
+			}
+			if line.op != '-' {
+				newCount++
+			}
+		}
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+		buf.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount))
+		for _, line := range lines[start:end] {
+			buf.WriteByte(line.op)
+			buf.WriteString(line.text)
+			buf.WriteByte('\n')
+		}
+		i = end
+	}
+	return buf.String()
+}
+
+func splitLines(p []byte) []string {
+	if len(p) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(p), "\n"), "\n")
+}
+
+// diffLines matches lines of a and b by the longest common subsequence.
+func diffLines(a []string, b []string) (lines []diffLine) {
+	lines = make([]diffLine, 0, len(a)+len(b))
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	for _, text := range a[:prefix] {
+		lines = append(lines, diffLine{op: ' ', text: text})
+	}
+	x, y := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	n, m := len(x), len(y)
+	if n*m > diffLimit {
+		for _, text := range x {
+			lines = append(lines, diffLine{op: '-', text: text})
+		}
+		for _, text := range y {
+			lines = append(lines, diffLine{op: '+', text: text})
+		}
+	} else {
+		table := make([][]int32, n+1)
+		for i := range table {
+			table[i] = make([]int32, m+1)
+		}
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				if x[i] == y[j] {
+					table[i][j] = table[i+1][j+1] + 1
+				} else if table[i+1][j] >= table[i][j+1] {
+					table[i][j] = table[i+1][j]
+				} else {
+					table[i][j] = table[i][j+1]
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < n || j < m {
+			switch {
+			case i < n && j < m && x[i] == y[j]:
+				lines = append(lines, diffLine{op: ' ', text: x[i]})
+				i++
+				j++
+			case i < n && (j == m || table[i+1][j] >= table[i][j+1]):
+				lines = append(lines, diffLine{op: '-', text: x[i]})
+				i++
+			default:
+				lines = append(lines, diffLine{op: '+', text: y[j]})
+				j++
+			}
+		}
+	}
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{op: ' ', text: text})
+	}
+	return
+}
+
+// colorDiff colors lines of unified diff, colors are disabled when stdout is not a terminal.
+func colorDiff(diff string) string {
+	if diff == "" || color.NoColor {
+		return diff
+	}
+	bold := color.New(color.Bold)
+	cyan := color.New(color.FgCyan)
+	red := color.New(color.FgRed)
+	green := color.New(color.FgGreen)
+	buf := strings.Builder{}
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if line == "" {
+			continue
+		}
+		text := strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(text, "--- ") || strings.HasPrefix(text, "+++ "):
+			text = bold.Sprint(text)
+		case strings.HasPrefix(text, "@@"):
+			text = cyan.Sprint(text)
+		case strings.HasPrefix(text, "-"):
+			text = red.Sprint(text)
+		case strings.HasPrefix(text, "+"):
+			text = green.Sprint(text)
+		}
+		buf.WriteString(text)
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codes
+
+import (
+	"github.com/fatih/color"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(items ...string) []byte {
+		if len(items) == 0 {
+			return nil
+		}
+		return []byte(strings.Join(items, "\n") + "\n")
+	}
+	cases := []struct {
+		name string
+		src  []byte
+		dst  []byte
+		diff string
+	}{
+		{
+			name: "equal",
+			src:  lines("a", "b"),
+			dst:  lines("a", "b"),
+		},
+		{
+			name: "created",
+			dst:  lines("a", "b"),
+			diff: "--- /dev/null\n+++ b/x.go\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "deleted",
+			src:  lines("a", "b"),
+			diff: "--- a/x.go\n+++ /dev/null\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name: "modified",
+			src:  lines("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+			dst:  lines("1", "2", "3", "4", "five", "6", "7", "8", "9"),
+			diff: "--- a/x.go\n+++ b/x.go\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "inserted",
+			src:  lines("1", "2"),
+			dst:  lines("1", "1.5", "2"),
+			diff: "--- a/x.go\n+++ b/x.go\n@@ -1,2 +1,3 @@\n 1\n+1.5\n 2\n",
+		},
+		{
+			name: "near changes are in one hunk",
+			src:  lines("1", "2", "3", "4", "5", "6", "7", "8"),
+			dst:  lines("one", "2", "3", "4", "5", "6", "7", "eight"),
+			diff: "--- a/x.go\n+++ b/x.go\n@@ -1,8 +1,8 @@\n-1\n+one\n 2\n 3\n 4\n 5\n 6\n 7\n-8\n+eight\n",
+		},
+		{
+			name: "far changes are in two hunks",
+			src:  lines("1", "2", "3", "4", "5", "6", "7", "8", "9", "10"),
+			dst:  lines("one", "2", "3", "4", "5", "6", "7", "8", "9", "ten"),
+			diff: "--- a/x.go\n+++ b/x.go\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+	}
+	for _, c := range cases {
+		if got := unifiedDiff("x.go", c.src, c.dst); got != c.diff {
+			t.Errorf("%s: got diff\n%s\nwant\n%s", c.name, got, c.diff)
+		}
+	}
+}
+
+func TestColorDiff(t *testing.T) {
+	noColor := color.NoColor
+	defer func() {
+		color.NoColor = noColor
+	}()
+	diff := "--- a/x.go\n+++ b/x.go\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n"
+	color.NoColor = true
+	if got := colorDiff(diff); got != diff {
+		t.Errorf("got %q, want uncolored diff", got)
+	}
+	color.NoColor = false
+	want := "\x1b[1m--- a/x.go\x1b[0m\n\x1b[1m+++ b/x.go\x1b[0m\n\x1b[36m@@ -1,2 +1,2 @@\x1b[0m\n a\n\x1b[31m-b\x1b[0m\n\x1b[32m+c\x1b[0m\n"
+	if got := colorDiff(diff); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
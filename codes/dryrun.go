@@ -0,0 +1,46 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codes
+
+import (
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// dryRun generates codes into a scratch in temp dir, and prints files which would be created, modified or deleted by codes, and their diffs.
+func dryRun(ctx *cli.Context, projectDir string, work string, rep *reporter) (err error) {
+	changes, previewErr := preview(ctx, projectDir, work, rep)
+	if previewErr != nil {
+		err = errors.Warning("fnc: codes dry run failed").WithCause(previewErr)
+		return
+	}
+	if len(changes) == 0 {
+		rep.message("fnc: nothing would be changed")
+		return
+	}
+	for _, c := range changes {
+		rep.message("fnc: %s would be %s", c.Filename, c.Kind)
+	}
+	if rep.json {
+		return
+	}
+	for _, c := range changes {
+		fmt.Print(colorDiff(unifiedDiff(c.Filename, c.Old, c.New)))
+	}
+	return
+}
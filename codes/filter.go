@@ -0,0 +1,77 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codes
+
+import (
+	"fmt"
+	"github.com/aacfactory/fnc/sources"
+	"path"
+	"strings"
+)
+
+// selectServices returns services which are matched by names and are not matched by exclude globs.
+// a name matches the @service name or the dir of service, all services are matched when names are empty.
+func selectServices(services []*sources.Service, names []string, excludes []string) (selected []*sources.Service, err error) {
+	selected = make([]*sources.Service, 0, len(services))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, service := range services {
+			if service.Name == name || service.Dir == name || path.Base(service.Dir) == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			err = fmt.Errorf("fnc: service %s was not found", name)
+			return
+		}
+	}
+	for _, exclude := range excludes {
+		if _, matchErr := path.Match(exclude, ""); matchErr != nil {
+			err = fmt.Errorf("fnc: exclude %s is invalid, %v", exclude, matchErr)
+			return
+		}
+	}
+	for _, service := range services {
+		if len(names) > 0 {
+			matched := false
+			for _, name := range names {
+				name = strings.TrimSpace(name)
+				if service.Name == name || service.Dir == name || path.Base(service.Dir) == name {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				continue
+			}
+		}
+		excluded := false
+		for _, exclude := range excludes {
+			for _, target := range []string{service.Name, service.Dir} {
+				if ok, _ := path.Match(exclude, target); ok {
+					excluded = true
+				}
+			}
+		}
+		if !excluded {
+			selected = append(selected, service)
+		}
+	}
+	return
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codes
+
+import (
+	"github.com/aacfactory/fnc/sources"
+	"strings"
+	"testing"
+)
+
+func TestSelectServices(t *testing.T) {
+	services := []*sources.Service{
+		{Name: "users", Dir: "modules/users"},
+		{Name: "orders", Dir: "modules/shop/orders"},
+		{Name: "order_items", Dir: "modules/shop/items"},
+		{Name: "admin", Dir: "modules/admin"},
+	}
+	cases := []struct {
+		name     string
+		names    []string
+		excludes []string
+		selected string
+		err      string
+	}{
+		{name: "all", selected: "users,orders,order_items,admin"},
+		{name: "service name", names: []string{"order_items"}, selected: "order_items"},
+		{name: "dir", names: []string{"modules/shop/orders"}, selected: "orders"},
+		{name: "base of dir", names: []string{"items", " users "}, selected: "users,order_items"},
+		{name: "not found", names: []string{"carts"}, err: "fnc: service carts was not found"},
+		{name: "exclude name glob", excludes: []string{"order*"}, selected: "users,admin"},
+		{name: "exclude dir glob", excludes: []string{"modules/shop/*"}, selected: "users,admin"},
+		{name: "names and excludes", names: []string{"users", "admin"}, excludes: []string{"adm?n"}, selected: "users"},
+		{name: "invalid exclude", excludes: []string{"[a"}, err: "fnc: exclude [a is invalid, syntax error in pattern"},
+	}
+	for _, c := range cases {
+		selected, err := selectServices(services, c.names, c.excludes)
+		if c.err != "" {
+			if err == nil || err.Error() != c.err {
+				t.Errorf("%s: got error %v, want %s", c.name, err, c.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		names := make([]string, 0, len(selected))
+		for _, service := range selected {
+			names = append(names, service.Name)
+		}
+		if got := strings.Join(names, ","); got != c.selected {
+			t.Errorf("%s: got %s, want %s", c.name, got, c.selected)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codes
+
+import (
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/fnc/sources"
+	"github.com/urfave/cli/v2"
+	"strings"
+)
+
+type generateOptions struct {
+	Work     string
+	Reporter *reporter
+	NoCache  bool
+	// Jobs is the max number of scratches which are generated at the same time, it is used when a part of services are generated
+	Jobs     int
+	Services []string
+	Excludes []string
+}
+
+// generate generates codes of selected services, services whose sources and generated files are not changed since last run are skipped.
+// when a part of services are generated, modules/fns.go is still generated with all services.
+func generate(ctx *cli.Context, projectDir string, opt generateOptions) (err error) {
+	services, servicesErr := loadServices(projectDir)
+	if servicesErr != nil {
+		err = errors.Warning("fnc: codes failed").WithCause(servicesErr)
+		return
+	}
+	// files under modules are rolled back when generation is aborted by signals or timeout, removed stale files are included
+	snap, snapErr := takeSnapshot(projectDir)
+	if snapErr != nil {
+		err = errors.Warning("fnc: codes failed").WithCause(snapErr)
+		return
+	}
+	defer func() {
+		if err == nil || ctx.Context.Err() == nil {
+			return
+		}
+		if restoreErr := snap.restore(); restoreErr != nil {
+			err = errors.Map(err).WithCause(restoreErr)
+			return
+		}
+		opt.Reporter.message("fnc: generation was aborted, written files have been rolled back")
+	}()
+	cached := loadCache(projectDir)
+	if staleErr := removeStaleFiles(projectDir, services, cached, opt.Reporter); staleErr != nil {
+		err = errors.Warning("fnc: codes failed").WithCause(staleErr)
+		return
+	}
+	if len(services) == 0 {
+		err = runInPlace(ctx, projectDir, opt.Work, opt.Reporter)
+		return
+	}
+	selected, selectErr := selectServices(services, opt.Services, opt.Excludes)
+	if selectErr != nil {
+		err = errors.Warning("fnc: codes failed").WithCause(selectErr)
+		return
+	}
+	if len(selected) == 0 {
+		err = errors.Warning("fnc: codes failed").WithCause(errors.Warning("no service is selected"))
+		return
+	}
+	current, currentErr := currentCache(projectDir, services)
+	if currentErr != nil {
+		err = errors.Warning("fnc: codes failed").WithCause(currentErr)
+		return
+	}
+	full := len(selected) == len(services)
+	targets := selected
+	if !opt.NoCache {
+		dirs, all := cached.stale(current)
+		if !all {
+			full = false
+			targets = make([]*sources.Service, 0, len(dirs))
+			for _, service := range selected {
+				for _, dir := range dirs {
+					if service.Dir == dir {
+						targets = append(targets, service)
+					}
+				}
+			}
+		}
+	}
+	if len(targets) == 0 && cached.sameServices(current) {
+		opt.Reporter.message("fnc: generated codes are up to date, use --no-cache to generate all")
+		return
+	}
+	if !full && len(targets) > 0 {
+		names := make([]string, 0, len(targets))
+		for _, target := range targets {
+			names = append(names, target.Name)
+		}
+		opt.Reporter.debugf("fnc: generating services: %s", strings.Join(names, ", "))
+	}
+	if full {
+		err = runInPlace(ctx, projectDir, opt.Work, opt.Reporter)
+	} else {
+		// services are added or removed when targets are empty, coding still runs with stubs to generate modules/fns.go
+		err = generateJobs(ctx, projectDir, opt, services, targets)
+	}
+	if err != nil {
+		return
+	}
+	// update cache by results, services which are not generated keep their previous entries
+	current, currentErr = currentCache(projectDir, services)
+	if currentErr != nil {
+		err = errors.Warning("fnc: codes failed").WithCause(currentErr)
+		return
+	}
+	current.merge(cached, targets)
+	if saveErr := current.save(projectDir); saveErr != nil {
+		err = errors.Warning("fnc: codes failed").WithCause(saveErr)
+		return
+	}
+	return
+}
+
+// runInPlace runs coding in project.
+func runInPlace(ctx *cli.Context, projectDir string, work string, rep *reporter) (err error) {
+	project, loadErr := load(projectDir, work)
+	if loadErr != nil {
+		err = errors.Warning("fnc: codes failed").WithCause(loadErr)
+		return
+	}
+	err = run(ctx, project, rep)
+	return
+}
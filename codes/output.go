@@ -0,0 +1,218 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codes
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/urfave/cli/v2"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	textOutput = "text"
+	jsonOutput = "json"
+)
+
+// reporter prints progress of codes, as text lines or as json events which are one per line.
+// it is shared by codes processes which are run at the same time, failures of them are collected into one summary.
+type reporter struct {
+	mutex  sync.Mutex
+	debug  bool
+	json   bool
+	beg    time.Time
+	last   time.Time
+	units  int
+	failed int
+	sum    *summary
+}
+
+func newReporter(debug bool, output string) (r *reporter, err error) {
+	if output != "" && output != textOutput && output != jsonOutput {
+		err = fmt.Errorf("fnc: output %s is invalid, text or json is supported", output)
+		return
+	}
+	now := time.Now()
+	r = &reporter{
+		debug: debug,
+		json:  output == jsonOutput,
+		beg:   now,
+		last:  now,
+		sum:   &summary{},
+	}
+	return
+}
+
+type eventError struct {
+	Code    int               `json:"code"`
+	Name    string            `json:"name"`
+	Message string            `json:"message"`
+	Meta    map[string]string `json:"meta,omitempty"`
+	Cause   *eventError       `json:"cause,omitempty"`
+}
+
+type unitEvent struct {
+	Type     string      `json:"type"`
+	UnitNo   int64       `json:"unitNo"`
+	UnitNum  int64       `json:"unitNum"`
+	Step     string      `json:"step"`
+	File     string      `json:"file,omitempty"`
+	Duration int64       `json:"durationMs"`
+	Error    *eventError `json:"error,omitempty"`
+}
+
+type messageEvent struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+type summaryEvent struct {
+	Type     string      `json:"type"`
+	Status   string      `json:"status"`
+	Units    int         `json:"units"`
+	Failed   int         `json:"failed"`
+	Duration int64       `json:"durationMs"`
+	Error    *eventError `json:"error,omitempty"`
+}
+
+// unit reports a result of codes process, duration is the time since the previous result.
+func (r *reporter) unit(no int64, num int64, step string, data interface{}, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	now := time.Now()
+	duration := now.Sub(r.last)
+	r.last = now
+	r.units++
+	if err != nil {
+		r.failed++
+		r.sum.add(step, err)
+	}
+	if r.json {
+		event := unitEvent{
+			Type:     "unit",
+			UnitNo:   no,
+			UnitNum:  num,
+			Step:     step,
+			Duration: duration.Milliseconds(),
+			Error:    newEventError(err),
+		}
+		if data != nil {
+			event.File = fmt.Sprint(data)
+		}
+		r.emit(event)
+		return
+	}
+	if r.debug {
+		fmt.Println(step, "->", fmt.Sprintf("[%d/%d]", no, num), data)
+		if err != nil {
+			fmt.Println(fmt.Sprintf("%+v", err))
+		}
+	}
+}
+
+// message prints a line, it is printed as a message event in json output.
+func (r *reporter) message(format string, args ...interface{}) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	text := fmt.Sprintf(format, args...)
+	if r.json {
+		r.emit(messageEvent{Type: "message", Message: text})
+		return
+	}
+	fmt.Println(text)
+}
+
+// debugf prints a line when debug is enabled.
+func (r *reporter) debugf(format string, args ...interface{}) {
+	if !r.debug {
+		return
+	}
+	r.message(format, args...)
+}
+
+// failures prints the summary of failures which are reported since last call, and returns an exit error which does not print them again.
+// err is returned when there is no failure, and failures are included in the final summary event in json output.
+func (r *reporter) failures(err error) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.json || err == nil || !r.sum.failed() {
+		return err
+	}
+	r.sum.print()
+	n := len(r.sum.failures)
+	r.sum = &summary{}
+	return cli.Exit(fmt.Sprintf("fnc: codes failed, %d errors", n), 1)
+}
+
+// finish prints the summary event in json output, the returned error does not print the error again.
+func (r *reporter) finish(err error) error {
+	if !r.json {
+		return r.failures(err)
+	}
+	event := summaryEvent{
+		Type:     "summary",
+		Status:   "succeeded",
+		Units:    r.units,
+		Failed:   r.failed,
+		Duration: time.Since(r.beg).Milliseconds(),
+	}
+	if err != nil {
+		event.Status = "failed"
+		event.Error = newEventError(err)
+	}
+	r.emit(event)
+	if err != nil {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
+
+func (r *reporter) emit(event interface{}) {
+	p, encodeErr := json.Marshal(event)
+	if encodeErr != nil {
+		p, _ = json.Marshal(messageEvent{Type: "message", Message: encodeErr.Error()})
+	}
+	_, _ = os.Stdout.Write(append(p, '\n'))
+}
+
+func newEventError(err error) (v *eventError) {
+	if err == nil {
+		return
+	}
+	if exit, ok := err.(cli.ExitCoder); ok {
+		v = &eventError{Code: exit.ExitCode(), Message: exit.Error()}
+		return
+	}
+	codeErr := errors.Map(err)
+	value := decodeError(codeErr)
+	v = &eventError{}
+	for target := v; ; target = target.Cause {
+		target.Code = value.Code
+		target.Name = value.Name
+		target.Message = value.Message
+		target.Meta = value.Meta
+		if value.Cause == nil {
+			break
+		}
+		value = *value.Cause
+		target.Cause = &eventError{}
+	}
+	return
+}
@@ -0,0 +1,271 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codes
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/fnc/sources"
+	"github.com/urfave/cli/v2"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// modulesFilename is the file which registers all services, it is generated by coding.
+const modulesFilename = "modules/fns.go"
+
+// generateJobs generates codes of selected services in scratches, then changes of them are applied when all of them are succeeded.
+// selected services are split into at most jobs batches, each batch has its own scratch, so coding runs once per batch.
+func generateJobs(ctx *cli.Context, projectDir string, opt generateOptions, services []*sources.Service, selected []*sources.Service) (err error) {
+	batches := splitServices(selected, opt.Jobs)
+	results := make([][]change, len(batches))
+	errs := make([]error, len(batches))
+	wg := sync.WaitGroup{}
+	for i, batch := range batches {
+		wg.Add(1)
+		go func(i int, batch []*sources.Service) {
+			defer wg.Done()
+			results[i], errs[i] = generateServices(ctx, projectDir, opt.Work, services, batch, opt.Reporter)
+		}(i, batch)
+	}
+	wg.Wait()
+	for _, batchErr := range errs {
+		if batchErr != nil {
+			err = batchErr
+			return
+		}
+	}
+	// modules/fns.go is generated by every batch, and they are same
+	merged := make(map[string]change)
+	for _, changes := range results {
+		for _, c := range changes {
+			merged[c.Filename] = c
+		}
+	}
+	changes := make([]change, 0, len(merged))
+	for _, c := range merged {
+		changes = append(changes, c)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Filename < changes[j].Filename
+	})
+	if err = applyChanges(projectDir, changes); err != nil {
+		err = errors.Warning("fnc: codes failed").WithCause(err)
+		return
+	}
+	return
+}
+
+// splitServices splits services into at most n batches round-robin, there is one batch at least, so modules/fns.go is generated when services are empty.
+func splitServices(services []*sources.Service, n int) (batches [][]*sources.Service) {
+	if n > len(services) {
+		n = len(services)
+	}
+	if n < 1 {
+		n = 1
+	}
+	batches = make([][]*sources.Service, n)
+	for i := range batches {
+		batches[i] = make([]*sources.Service, 0, len(services)/n+1)
+	}
+	for i, service := range services {
+		batches[i%n] = append(batches[i%n], service)
+	}
+	return
+}
+
+// generateServices generates codes of selected services only, and returns changes of them which are not applied.
+// coding is run in a scratch which has selected services, packages imported by them and stubs of other services,
+// then changes of generated files of selected services and modules/fns.go are returned.
+func generateServices(ctx *cli.Context, projectDir string, work string, services []*sources.Service, selected []*sources.Service, rep *reporter) (changes []change, err error) {
+	found := make(map[string]struct{})
+	for _, service := range selected {
+		found[service.Dir] = struct{}{}
+		for _, dep := range service.Deps {
+			found[dep] = struct{}{}
+		}
+	}
+	dirs := make([]string, 0, len(found))
+	for item := range found {
+		dirs = append(dirs, item)
+	}
+	sort.Strings(dirs)
+	s, scratchErr := newScratch(projectDir, dirs)
+	if scratchErr != nil {
+		err = errors.Warning("fnc: codes failed").WithCause(scratchErr)
+		return
+	}
+	defer s.Close()
+	for _, service := range services {
+		if _, has := found[service.Dir]; has {
+			continue
+		}
+		if err = stubService(projectDir, s.dir, service); err != nil {
+			err = errors.Warning("fnc: codes failed").WithCause(err)
+			return
+		}
+	}
+	project, loadErr := load(s.dir, work)
+	if loadErr != nil {
+		err = errors.Warning("fnc: codes failed").WithCause(loadErr)
+		return
+	}
+	if err = run(ctx, project, rep); err != nil {
+		return
+	}
+	all, changesErr := s.changes()
+	if changesErr != nil {
+		err = errors.Warning("fnc: codes failed").WithCause(changesErr)
+		return
+	}
+	changes = serviceChanges(all, services, selected)
+	return
+}
+
+// stubService writes a doc.go which has the package doc of service only into scratch,
+// so the service is still registered in modules/fns.go, but its sources are not copied and loaded.
+func stubService(projectDir string, dir string, service *sources.Service) (err error) {
+	filename := filepath.Join(projectDir, filepath.FromSlash(service.Pos.Filename))
+	file, parseErr := parser.ParseFile(token.NewFileSet(), filename, nil, parser.PackageClauseOnly|parser.ParseComments)
+	if parseErr != nil {
+		err = fmt.Errorf("fnc: read doc of %s failed, %v", service.Dir, parseErr)
+		return
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, 256))
+	if file.Doc != nil {
+		for _, comment := range file.Doc.List {
+			buf.WriteString(comment.Text)
+			buf.WriteByte('\n')
+		}
+	}
+	buf.WriteString(fmt.Sprintf("package %s\n", file.Name.Name))
+	stubDir := filepath.Join(dir, filepath.FromSlash(service.Dir))
+	if err = os.MkdirAll(stubDir, 0755); err != nil {
+		err = fmt.Errorf("fnc: write stub of %s failed, %v", service.Dir, err)
+		return
+	}
+	if err = os.WriteFile(filepath.Join(stubDir, "doc.go"), buf.Bytes(), 0644); err != nil {
+		err = fmt.Errorf("fnc: write stub of %s failed, %v", service.Dir, err)
+		return
+	}
+	return
+}
+
+// serviceChanges returns changes of generated files which belong to selected services, and the change of modules/fns.go.
+func serviceChanges(changes []change, services []*sources.Service, selected []*sources.Service) (v []change) {
+	v = make([]change, 0, len(changes))
+	for _, c := range changes {
+		if c.Filename == modulesFilename {
+			if c.Kind != deletedChange && isGenerated(c.New) {
+				v = append(v, c)
+			}
+			continue
+		}
+		owner, has := ownerOf(c.Filename, services)
+		if !has || !containsService(selected, owner.Dir) {
+			continue
+		}
+		if !isGenerated(c.New) && !isGenerated(c.Old) {
+			continue
+		}
+		v = append(v, c)
+	}
+	return
+}
+
+// ownerOf returns the service which contains the file, the deepest one is returned for nested services.
+func ownerOf(filename string, services []*sources.Service) (owner *sources.Service, has bool) {
+	for _, service := range services {
+		if strings.HasPrefix(filename, service.Dir+"/") && (owner == nil || len(service.Dir) > len(owner.Dir)) {
+			owner = service
+			has = true
+		}
+	}
+	return
+}
+
+func containsService(services []*sources.Service, dir string) bool {
+	for _, s := range services {
+		if s.Dir == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// applyChanges writes changes into project, files are written into temp files first, then renamed.
+func applyChanges(projectDir string, changes []change) (err error) {
+	temps := make(map[string]string)
+	defer func() {
+		for _, temp := range temps {
+			_ = os.Remove(temp)
+		}
+	}()
+	for _, c := range changes {
+		if c.Kind == deletedChange {
+			continue
+		}
+		filename := filepath.Join(projectDir, filepath.FromSlash(c.Filename))
+		if err = os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			err = fmt.Errorf("fnc: write %s failed, %v", c.Filename, err)
+			return
+		}
+		temp, tempErr := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*.tmp")
+		if tempErr != nil {
+			err = fmt.Errorf("fnc: write %s failed, %v", c.Filename, tempErr)
+			return
+		}
+		temps[filename] = temp.Name()
+		_, writeErr := temp.Write(c.New)
+		closeErr := temp.Close()
+		if writeErr == nil {
+			writeErr = closeErr
+		}
+		if writeErr != nil {
+			err = fmt.Errorf("fnc: write %s failed, %v", c.Filename, writeErr)
+			return
+		}
+		if err = os.Chmod(temp.Name(), 0644); err != nil {
+			err = fmt.Errorf("fnc: write %s failed, %v", c.Filename, err)
+			return
+		}
+	}
+	for filename, temp := range temps {
+		if err = os.Rename(temp, filename); err != nil {
+			err = fmt.Errorf("fnc: write %s failed, %v", filename, err)
+			return
+		}
+		delete(temps, filename)
+	}
+	for _, c := range changes {
+		if c.Kind != deletedChange {
+			continue
+		}
+		if err = os.Remove(filepath.Join(projectDir, filepath.FromSlash(c.Filename))); err != nil && !os.IsNotExist(err) {
+			err = fmt.Errorf("fnc: remove %s failed, %v", c.Filename, err)
+			return
+		}
+		err = nil
+	}
+	return
+}
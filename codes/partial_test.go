@@ -0,0 +1,55 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codes
+
+import (
+	"github.com/aacfactory/fnc/sources"
+	"strings"
+	"testing"
+)
+
+func TestSplitServices(t *testing.T) {
+	services := []*sources.Service{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}}
+	cases := []struct {
+		services []*sources.Service
+		n        int
+		batches  string
+	}{
+		{services: services, n: 1, batches: "a,b,c,d,e"},
+		{services: services, n: 2, batches: "a,c,e|b,d"},
+		{services: services, n: 3, batches: "a,d|b,e|c"},
+		{services: services, n: 8, batches: "a|b|c|d|e"},
+		{services: services, n: 0, batches: "a,b,c,d,e"},
+		{services: nil, n: 4, batches: ""},
+	}
+	for _, c := range cases {
+		batches := make([]string, 0, c.n)
+		for _, batch := range splitServices(c.services, c.n) {
+			names := make([]string, 0, len(batch))
+			for _, service := range batch {
+				names = append(names, service.Name)
+			}
+			batches = append(batches, strings.Join(names, ","))
+		}
+		if got := strings.Join(batches, "|"); got != c.batches {
+			t.Errorf("%d: got %s, want %s", c.n, got, c.batches)
+		}
+	}
+	if batches := splitServices(nil, 4); len(batches) != 1 {
+		t.Errorf("got %d batches of no service, want 1", len(batches))
+	}
+}
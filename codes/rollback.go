@@ -0,0 +1,77 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codes
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// snapshot keeps files under modules before codes are generated in place, they are restored when generation is aborted.
+type snapshot struct {
+	dir   string
+	files map[string][]byte
+}
+
+func takeSnapshot(projectDir string) (s *snapshot, err error) {
+	dir := filepath.Join(projectDir, "modules")
+	files := make(map[string][]byte)
+	if _, statErr := os.Stat(dir); statErr == nil {
+		files, err = readTree(dir)
+		if err != nil {
+			return
+		}
+	}
+	s = &snapshot{
+		dir:   dir,
+		files: files,
+	}
+	return
+}
+
+// restore writes back changed files and removes generated files which are created after snapshot.
+func (s *snapshot) restore() (err error) {
+	current, readErr := readTree(s.dir)
+	if readErr != nil {
+		current = make(map[string][]byte)
+	}
+	changes := make([]change, 0, 1)
+	for name, p := range current {
+		old, has := s.files[name]
+		if !has {
+			if isGenerated(p) {
+				changes = append(changes, change{Filename: name, Kind: deletedChange})
+			}
+			continue
+		}
+		if !bytes.Equal(old, p) {
+			changes = append(changes, change{Filename: name, Kind: modifiedChange, New: old})
+		}
+	}
+	for name, p := range s.files {
+		if _, has := current[name]; !has {
+			changes = append(changes, change{Filename: name, Kind: createdChange, New: p})
+		}
+	}
+	if err = applyChanges(s.dir, changes); err != nil {
+		err = fmt.Errorf("fnc: rollback failed, %v", err)
+		return
+	}
+	return
+}
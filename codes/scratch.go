@@ -0,0 +1,312 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codes
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/aacfactory/fnc/sources"
+	"golang.org/x/mod/modfile"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	createdChange  = "created"
+	modifiedChange = "modified"
+	deletedChange  = "deleted"
+)
+
+// change is a file which is different between project and its scratch.
+type change struct {
+	Filename string
+	Kind     string
+	Old      []byte
+	New      []byte
+}
+
+// scratch is a copy of files which coding needs, they are go.mod, go.sum and dirs of packages, so the project is not touched.
+// it is placed in the temp dir, relative replaces of go.mod are rewritten to absolute paths, so they are still available.
+type scratch struct {
+	src string
+	dir string
+	// dirs are copied dirs, they are slash separated and relative to project dir
+	dirs []string
+}
+
+// newScratch copies go.mod, go.sum and files of dirs into a temp dir, sub dirs of dirs are not copied.
+func newScratch(projectDir string, dirs []string) (s *scratch, err error) {
+	dir, mkErr := os.MkdirTemp("", "fnc-codes-*")
+	if mkErr != nil {
+		err = fmt.Errorf("fnc: create scratch of project failed, %v", mkErr)
+		return
+	}
+	s = &scratch{
+		src:  projectDir,
+		dir:  filepath.ToSlash(dir),
+		dirs: dirs,
+	}
+	err = s.copyMod()
+	if err == nil {
+		for _, item := range dirs {
+			if err = copyFiles(filepath.Join(projectDir, filepath.FromSlash(item)), filepath.Join(dir, filepath.FromSlash(item))); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		s.Close()
+		s = nil
+		return
+	}
+	return
+}
+
+// copyMod copies go.mod and go.sum, relative replaces are rewritten to absolute paths.
+func (s *scratch) copyMod() (err error) {
+	modFilename := filepath.Join(s.src, "go.mod")
+	p, readErr := os.ReadFile(modFilename)
+	if readErr != nil {
+		err = fmt.Errorf("fnc: read %s failed, %v", modFilename, readErr)
+		return
+	}
+	mf, parseErr := modfile.Parse(modFilename, p, nil)
+	if parseErr != nil {
+		err = fmt.Errorf("fnc: parse %s failed, %v", modFilename, parseErr)
+		return
+	}
+	for _, replace := range mf.Replace {
+		if replace.New.Version != "" || !modfile.IsDirectoryPath(replace.New.Path) || filepath.IsAbs(replace.New.Path) {
+			continue
+		}
+		target := filepath.ToSlash(filepath.Join(s.src, filepath.FromSlash(replace.New.Path)))
+		if err = mf.AddReplace(replace.Old.Path, replace.Old.Version, target, ""); err != nil {
+			err = fmt.Errorf("fnc: rewrite replace of %s failed, %v", modFilename, err)
+			return
+		}
+	}
+	if p, err = mf.Format(); err != nil {
+		err = fmt.Errorf("fnc: format %s failed, %v", modFilename, err)
+		return
+	}
+	if err = os.WriteFile(filepath.Join(s.dir, "go.mod"), p, 0644); err != nil {
+		err = fmt.Errorf("fnc: copy go.mod failed, %v", err)
+		return
+	}
+	if sum, sumErr := os.ReadFile(filepath.Join(s.src, "go.sum")); sumErr == nil {
+		if err = os.WriteFile(filepath.Join(s.dir, "go.sum"), sum, 0644); err != nil {
+			err = fmt.Errorf("fnc: copy go.sum failed, %v", err)
+			return
+		}
+	}
+	return
+}
+
+func (s *scratch) Close() {
+	_ = os.RemoveAll(s.dir)
+}
+
+// changes compares files of scratch with files of project, only dirs which are in scratch are compared.
+func (s *scratch) changes() (changes []change, err error) {
+	news, newsErr := readTree(s.dir)
+	if newsErr != nil {
+		err = newsErr
+		return
+	}
+	delete(news, "go.mod")
+	delete(news, "go.sum")
+	dirs := make(map[string]struct{})
+	for _, item := range s.dirs {
+		dirs[item] = struct{}{}
+	}
+	for name := range news {
+		dirs[path.Dir(name)] = struct{}{}
+	}
+	olds := make(map[string][]byte)
+	for item := range dirs {
+		if err = readFiles(filepath.Join(s.src, filepath.FromSlash(item)), item, olds); err != nil {
+			return
+		}
+	}
+	changes = make([]change, 0, 1)
+	for name, p := range news {
+		old, has := olds[name]
+		if !has {
+			changes = append(changes, change{Filename: name, Kind: createdChange, New: p})
+			continue
+		}
+		if !bytes.Equal(old, p) {
+			changes = append(changes, change{Filename: name, Kind: modifiedChange, Old: old, New: p})
+		}
+	}
+	for name, p := range olds {
+		if _, has := news[name]; !has {
+			changes = append(changes, change{Filename: name, Kind: deletedChange, Old: p})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Filename < changes[j].Filename
+	})
+	return
+}
+
+// packageDirs returns dirs under modules and dirs of packages which are imported by services, they are what coding needs.
+func packageDirs(projectDir string, services []*sources.Service) (dirs []string, err error) {
+	found := make(map[string]struct{})
+	modulesDir := filepath.Join(projectDir, "modules")
+	err = filepath.WalkDir(modulesDir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if os.IsNotExist(walkErr) {
+				return filepath.SkipDir
+			}
+			return walkErr
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != modulesDir && skipDir(d.Name()) {
+			return filepath.SkipDir
+		}
+		rel, relErr := filepath.Rel(projectDir, path)
+		if relErr != nil {
+			return relErr
+		}
+		found[filepath.ToSlash(rel)] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("fnc: read %s failed, %v", modulesDir, err)
+		return
+	}
+	for _, service := range services {
+		for _, dep := range service.Deps {
+			found[dep] = struct{}{}
+		}
+	}
+	dirs = make([]string, 0, len(found))
+	for item := range found {
+		dirs = append(dirs, item)
+	}
+	sort.Strings(dirs)
+	return
+}
+
+// skipDir returns true when the dir is not a part of project sources.
+func skipDir(name string) bool {
+	return name == ".git" || name == ".idea" || name == ".vscode" || name == ".fnc" || name == "testdata"
+}
+
+// readTree reads all files of dir, keys are slash separated paths relative to dir.
+func readTree(dir string) (files map[string][]byte, err error) {
+	files = make(map[string][]byte)
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() {
+			if path != dir && skipDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, relErr := filepath.Rel(dir, path)
+		if relErr != nil {
+			return relErr
+		}
+		p, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return readErr
+		}
+		files[filepath.ToSlash(rel)] = p
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("fnc: read %s failed, %v", dir, err)
+		return
+	}
+	return
+}
+
+// readFiles reads regular files of dir into files, sub dirs are not read, keys are prefix joined with names.
+func readFiles(dir string, prefix string, files map[string][]byte) (err error) {
+	entries, readErr := os.ReadDir(dir)
+	if readErr != nil {
+		if os.IsNotExist(readErr) {
+			return
+		}
+		err = fmt.Errorf("fnc: read %s failed, %v", dir, readErr)
+		return
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		p, fileErr := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if fileErr != nil {
+			err = fmt.Errorf("fnc: read %s failed, %v", filepath.Join(dir, entry.Name()), fileErr)
+			return
+		}
+		files[path.Join(prefix, entry.Name())] = p
+	}
+	return
+}
+
+// copyFiles copies regular files of src into dst, sub dirs are not copied.
+func copyFiles(src string, dst string) (err error) {
+	files := make(map[string][]byte)
+	if err = readFiles(src, "", files); err != nil {
+		return
+	}
+	if err = os.MkdirAll(dst, 0755); err != nil {
+		err = fmt.Errorf("fnc: copy %s failed, %v", src, err)
+		return
+	}
+	for name, p := range files {
+		if err = os.WriteFile(filepath.Join(dst, name), p, 0644); err != nil {
+			err = fmt.Errorf("fnc: copy %s failed, %v", src, err)
+			return
+		}
+	}
+	return
+}
+
+// generatedHeader is written at the top of files which are generated by fnc and forg.
+const generatedHeader = "automatically generated, DON'T EDIT IT"
+
+// isGenerated returns true when the leading comments of file contain the generated header.
+func isGenerated(p []byte) bool {
+	for _, line := range strings.Split(string(p), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "//") {
+			return false
+		}
+		if strings.Contains(line, generatedHeader) {
+			return true
+		}
+	}
+	return false
+}
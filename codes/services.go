@@ -0,0 +1,99 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/aacfactory/fnc/sources"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// loadServices returns services under modules of project, they are parsed by sources and sorted by dir.
+// problems of annotations are not checked here, they are reported by coding and fnc lint.
+func loadServices(projectDir string) (services []*sources.Service, err error) {
+	project, loadErr := sources.Load(projectDir)
+	if loadErr != nil {
+		err = fmt.Errorf("fnc: list services failed, %v", loadErr)
+		return
+	}
+	services = project.Services
+	return
+}
+
+// serviceFiles returns files of service, files of sub services are excluded.
+// keys are slash separated paths relative to project dir.
+func serviceFiles(projectDir string, service *sources.Service, services []*sources.Service) (files map[string][]byte, err error) {
+	root := filepath.Join(projectDir, filepath.FromSlash(service.Dir))
+	files = make(map[string][]byte)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		rel, relErr := filepath.Rel(projectDir, path)
+		if relErr != nil {
+			return relErr
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if path == root {
+				return nil
+			}
+			if strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			for _, other := range services {
+				if other.Dir == rel {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || filepath.Ext(path) != ".go" {
+			return nil
+		}
+		p, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return readErr
+		}
+		files[rel] = p
+		return nil
+	})
+	return
+}
+
+// hashFiles returns sha256 of files, the result is stable for the same files.
+func hashFiles(files map[string][]byte) string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		sum := sha256.Sum256(files[name])
+		h.Write(sum[:])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
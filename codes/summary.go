@@ -0,0 +1,138 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codes
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"sort"
+	"strings"
+)
+
+// summary collects failed units of a codes process.
+type summary struct {
+	failures []failure
+}
+
+type failure struct {
+	key string
+	err errors.CodeError
+}
+
+func (s *summary) add(step string, err error) {
+	if err == nil {
+		return
+	}
+	codeErr := errors.Map(err)
+	s.failures = append(s.failures, failure{
+		key: failureKey(step, codeErr),
+		err: codeErr,
+	})
+}
+
+func (s *summary) failed() bool {
+	return len(s.failures) > 0
+}
+
+// print prints failures grouped by service or file.
+func (s *summary) print() {
+	if !s.failed() {
+		return
+	}
+	groups := make(map[string][]errors.CodeError)
+	keys := make([]string, 0, 1)
+	for _, f := range s.failures {
+		if _, has := groups[f.key]; !has {
+			keys = append(keys, f.key)
+		}
+		groups[f.key] = append(groups[f.key], f.err)
+	}
+	sort.Strings(keys)
+	fmt.Println(fmt.Sprintf("fnc: %d errors in %d services or files", len(s.failures), len(keys)))
+	for _, key := range keys {
+		fmt.Println(fmt.Sprintf("  %s:", key))
+		for _, err := range groups[key] {
+			fmt.Println(fmt.Sprintf("    - %s", errorMessage(err)))
+		}
+	}
+}
+
+// err returns all failures as one error.
+func (s *summary) err() (err error) {
+	if !s.failed() {
+		return
+	}
+	errs := errors.MakeErrors()
+	for _, f := range s.failures {
+		errs.Append(f.err.WithMeta("unit", f.key))
+	}
+	err = errs.Error()
+	return
+}
+
+type errorValue struct {
+	Code    int               `json:"code"`
+	Name    string            `json:"name"`
+	Message string            `json:"message"`
+	Meta    map[string]string `json:"meta"`
+	Cause   *errorValue       `json:"cause"`
+}
+
+func decodeError(err errors.CodeError) (v errorValue) {
+	p, encodeErr := json.Marshal(err)
+	if encodeErr != nil {
+		v.Message = err.Message()
+		return
+	}
+	if decodeErr := json.Unmarshal(p, &v); decodeErr != nil {
+		v.Message = err.Message()
+	}
+	return
+}
+
+// failureKey returns the service or file of error, which is found in meta of error and its causes.
+func failureKey(step string, err errors.CodeError) string {
+	for v := decodeError(err); ; v = *v.Cause {
+		for _, name := range []string{"service", "filename", "file", "dir"} {
+			if value := strings.TrimSpace(v.Meta[name]); value != "" {
+				return value
+			}
+		}
+		if v.Cause == nil {
+			break
+		}
+	}
+	if step = strings.TrimSpace(step); step != "" {
+		return step
+	}
+	return "unknown"
+}
+
+// errorMessage joins messages of error and its causes.
+func errorMessage(err errors.CodeError) string {
+	messages := make([]string, 0, 1)
+	for v := decodeError(err); ; v = *v.Cause {
+		if v.Message != "" {
+			messages = append(messages, v.Message)
+		}
+		if v.Cause == nil {
+			break
+		}
+	}
+	return strings.Join(messages, ": ")
+}
@@ -0,0 +1,216 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codes
+
+import (
+	"github.com/aacfactory/fnc/sources"
+	"github.com/urfave/cli/v2"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	watchInterval = 500 * time.Millisecond
+	watchDebounce = 300 * time.Millisecond
+)
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// watch polls go files under modules and packages imported by services, and generates services which are affected by changes.
+// services are loaded again before each run, generated files are ignored, so files written by coding do not trigger another run.
+func watch(ctx *cli.Context, projectDir string, opt generateOptions) (err error) {
+	rep := opt.Reporter
+	services, _ := loadServices(projectDir)
+	stamps := scanSources(projectDir, services)
+	rep.message("fnc: watching %s, press ctrl+c to stop", projectDir)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	pending := make(map[string]struct{})
+	var lastChanged time.Time
+	for {
+		select {
+		case <-ctx.Context.Done():
+			return
+		case <-ticker.C:
+		}
+		current := scanSources(projectDir, services)
+		for _, name := range changedFiles(stamps, current) {
+			pending[name] = struct{}{}
+			lastChanged = time.Now()
+		}
+		stamps = current
+		if len(pending) == 0 || time.Since(lastChanged) < watchDebounce {
+			continue
+		}
+		names := make([]string, 0, len(pending))
+		for name := range pending {
+			names = append(names, name)
+		}
+		pending = make(map[string]struct{})
+		beg := time.Now()
+		var loadErr error
+		if services, loadErr = loadServices(projectDir); loadErr != nil {
+			rep.message("fnc: [%s] load services failed, %v", beg.Format("15:04:05"), loadErr)
+			continue
+		}
+		runOpt := opt
+		target := "changed services"
+		if dirs := affectedServices(projectDir, names, services); dirs != nil {
+			selected, selectErr := selectServices(services, opt.Services, opt.Excludes)
+			if selectErr != nil {
+				rep.message("fnc: [%s] %v", beg.Format("15:04:05"), selectErr)
+				continue
+			}
+			runOpt.Services = make([]string, 0, len(dirs))
+			for _, dir := range dirs {
+				if containsService(selected, dir) {
+					runOpt.Services = append(runOpt.Services, dir)
+				}
+			}
+			if len(runOpt.Services) == 0 {
+				rep.debugf("fnc: [%s] %s are not selected", beg.Format("15:04:05"), strings.Join(dirs, ", "))
+				stamps = scanSources(projectDir, services)
+				continue
+			}
+			target = strings.Join(runOpt.Services, ", ")
+		}
+		if runErr := generate(ctx, projectDir, runOpt); runErr != nil {
+			rep.message("fnc: [%s] %s failed", beg.Format("15:04:05"), target)
+			rep.message("%v", rep.failures(runErr))
+		} else {
+			rep.message("fnc: [%s] %s generated in %s", beg.Format("15:04:05"), target, time.Since(beg).Round(time.Millisecond))
+		}
+		// files written by coding are not changes of sources
+		stamps = scanSources(projectDir, services)
+	}
+}
+
+// affectedServices returns dirs of services which own or import changed files.
+// nil is returned when some file belongs to no service, e.g. a service is added or removed, then all services are checked by cache.
+func affectedServices(projectDir string, filenames []string, services []*sources.Service) (dirs []string) {
+	found := make(map[string]struct{})
+	for _, filename := range filenames {
+		rel, relErr := filepath.Rel(projectDir, filename)
+		if relErr != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		dir := path.Dir(rel)
+		matched := false
+		if owner, has := ownerOf(rel, services); has {
+			found[owner.Dir] = struct{}{}
+			matched = true
+		}
+		for _, service := range services {
+			for _, dep := range service.Deps {
+				if dep == dir {
+					found[service.Dir] = struct{}{}
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			return nil
+		}
+	}
+	dirs = make([]string, 0, len(found))
+	for dir := range found {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return
+}
+
+// scanSources returns stamps of go source files under modules and in packages which are imported by services.
+func scanSources(projectDir string, services []*sources.Service) (stamps map[string]fileStamp) {
+	stamps = make(map[string]fileStamp)
+	modulesDir := filepath.Join(projectDir, "modules")
+	_ = filepath.WalkDir(modulesDir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != modulesDir && (strings.HasPrefix(d.Name(), ".") || skipDir(d.Name())) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		stampFile(stamps, path, d)
+		return nil
+	})
+	for _, service := range services {
+		for _, dep := range service.Deps {
+			dir := filepath.Join(projectDir, filepath.FromSlash(dep))
+			if strings.HasPrefix(dep+"/", "modules/") {
+				continue
+			}
+			entries, readErr := os.ReadDir(dir)
+			if readErr != nil {
+				continue
+			}
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					stampFile(stamps, filepath.Join(dir, entry.Name()), entry)
+				}
+			}
+		}
+	}
+	return
+}
+
+func stampFile(stamps map[string]fileStamp, filename string, d fs.DirEntry) {
+	if filepath.Ext(filename) != ".go" || d.Name() == "fns.go" {
+		return
+	}
+	info, infoErr := d.Info()
+	if infoErr != nil {
+		return
+	}
+	stamps[filename] = fileStamp{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+	}
+}
+
+// changedFiles returns created, modified and removed files, modified generated files are skipped.
+func changedFiles(prev map[string]fileStamp, current map[string]fileStamp) (names []string) {
+	names = make([]string, 0, 1)
+	for name, stamp := range current {
+		old, has := prev[name]
+		if has && old == stamp {
+			continue
+		}
+		if p, readErr := os.ReadFile(name); readErr == nil && isGenerated(p) {
+			continue
+		}
+		names = append(names, name)
+	}
+	for name := range prev {
+		if _, has := current[name]; !has {
+			names = append(names, name)
+		}
+	}
+	return
+}
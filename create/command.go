@@ -39,6 +39,11 @@ var Command = &cli.Command{
 			Required: true,
 			Usage:    "project go mod path",
 		},
+		&cli.BoolFlag{
+			Name:     "tls",
+			Required: false,
+			Usage:    "enable http tls in config, certificates can be created by `fnc ssc --full ssl`",
+		},
 	},
 	Action: func(ctx *cli.Context) (err error) {
 		projectDir := strings.TrimSpace(ctx.Args().First())
@@ -58,12 +63,15 @@ var Command = &cli.Command{
 			err = errors.Warning("fnc: create fns project failed").WithCause(errors.Warning("path is required")).WithMeta("dir", projectDir)
 			return
 		}
-		writeErr := files.Write(ctx.Context, projectPath, projectDir)
+		writeErr := files.Write(ctx.Context, projectPath, projectDir, ctx.Bool("tls"))
 		if writeErr != nil {
 			err = errors.Warning("fnc: create fns project failed").WithCause(writeErr).WithMeta("dir", projectDir).WithMeta("path", projectPath)
 			return
 		}
 		fmt.Println("fnc: project has been created, please run `go mod tidy` to fetch requires!")
+		if ctx.Bool("tls") {
+			fmt.Println("fnc: http tls is enabled, please run `fnc ssc --full ssl` in project dir to create certificates!")
+		}
 		return
 	},
 }
@@ -26,7 +26,7 @@ import (
 	"strings"
 )
 
-func NewConfigFiles(dir string) (v []*ConfigFile, err error) {
+func NewConfigFiles(dir string, tls bool) (v []*ConfigFile, err error) {
 	v = make([]*ConfigFile, 0, 1)
 	// root
 	root, rootErr := NewConfigFile("", dir)
@@ -34,6 +34,7 @@ func NewConfigFiles(dir string) (v []*ConfigFile, err error) {
 		err = rootErr
 		return
 	}
+	root.tls = tls
 	v = append(v, root)
 	// local
 	local, localErr := NewConfigFile("local", dir)
@@ -108,6 +109,7 @@ type ConfigFile struct {
 	kind     string
 	dir      string
 	filename string
+	tls      bool
 }
 
 func (cf *ConfigFile) Name() (name string) {
@@ -198,6 +200,15 @@ func (cf *ConfigFile) Write(ctx context.Context) (err error) {
 		config.Http = &HttpConfig{
 			Port: 18080,
 		}
+		if cf.tls {
+			// files are created by `fnc ssc --full ssl`
+			config.Http.TLS = &TLSConfig{
+				Cert:       "ssl/server.crt",
+				Key:        "ssl/server.key",
+				ClientCA:   "ssl/ca.crt",
+				ClientAuth: "require-and-verify",
+			}
+		}
 		break
 	}
 	p, encodeErr := yaml.Marshal(config)
@@ -240,5 +251,15 @@ type AutoMaxProcsConfig struct {
 }
 
 type HttpConfig struct {
-	Port int `json:"port" yaml:"port,omitempty"`
+	Port int        `json:"port" yaml:"port,omitempty"`
+	TLS  *TLSConfig `json:"tls" yaml:"tls,omitempty"`
+}
+
+// TLSConfig
+// ClientAuth is one of no, request, require-any, verify-if-given and require-and-verify.
+type TLSConfig struct {
+	Cert       string `json:"cert" yaml:"cert,omitempty"`
+	Key        string `json:"key" yaml:"key,omitempty"`
+	ClientCA   string `json:"clientCA" yaml:"clientCA,omitempty"`
+	ClientAuth string `json:"clientAuth" yaml:"clientAuth,omitempty"`
 }
@@ -27,7 +27,7 @@ import (
 	"time"
 )
 
-func Write(ctx context.Context, path string, dir string) (err error) {
+func Write(ctx context.Context, path string, dir string, tls bool) (err error) {
 	if files.ExistFile(filepath.Join(dir, "go.mod")) {
 		err = errors.Warning("fnc: go.mod is exist")
 		return
@@ -41,7 +41,7 @@ func Write(ctx context.Context, path string, dir string) (err error) {
 	}
 	process.Add("mod: writing", codes.Unit(mod))
 	// configs
-	configs, configsErr := NewConfigFiles(dir)
+	configs, configsErr := NewConfigFiles(dir, tls)
 	if configsErr != nil {
 		err = configsErr
 		return
@@ -205,7 +205,7 @@ func hello(ctx context.Context, argument HelloArgument) (result HelloResults, er
 `
 	)
 
-	err = os.WriteFile(filepath.ToSlash(filepath.Join(dir, "hello.go")), []byte(doc), 0600)
+	err = os.WriteFile(filepath.ToSlash(filepath.Join(dir, "hello.go")), []byte(hello), 0600)
 	if err != nil {
 		err = errors.Warning("forg: modules file write failed").WithCause(err).WithMeta("filename", filepath.ToSlash(filepath.Join(dir, "hello.go")))
 		return
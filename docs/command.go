@@ -0,0 +1,64 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package docs
+
+import (
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/fnc/sources"
+	"github.com/urfave/cli/v2"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var Command = &cli.Command{
+	Name:        "docs",
+	Aliases:     nil,
+	Usage:       "fnc docs {openapi|md|html} {project path}",
+	Description: "generate documents of services and fns",
+	ArgsUsage:   "",
+	Category:    "",
+	Subcommands: []*cli.Command{
+		openapiCommand,
+		markdownCommand,
+		htmlCommand,
+	},
+}
+
+// load returns the project in args, it fails when there are problems in annotations of project.
+func load(ctx *cli.Context) (project *sources.Project, err error) {
+	projectDir := strings.TrimSpace(ctx.Args().First())
+	if projectDir == "" {
+		projectDir = "."
+	}
+	project, err = sources.LoadValid(projectDir)
+	return
+}
+
+// write writes p into filename, dir of filename is created when it is absent.
+func write(filename string, p []byte) (err error) {
+	if err = os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		err = errors.Warning(fmt.Sprintf("make dir of %s failed", filename)).WithCause(err)
+		return
+	}
+	if err = os.WriteFile(filename, p, 0644); err != nil {
+		err = errors.Warning(fmt.Sprintf("write %s failed", filename)).WithCause(err)
+		return
+	}
+	return
+}
@@ -0,0 +1,215 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package docs
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/aacfactory/fnc/sources"
+	"html"
+	"strings"
+)
+
+var htmlCommand = newSiteCommand("html", "fnc docs html --out ./docs {project path}", &htmlRenderer{})
+
+const htmlStyle = `body{font-family:-apple-system,"Segoe UI",Helvetica,Arial,sans-serif;margin:0 auto;max-width:1080px;padding:24px;color:#24292f;line-height:1.5}
+a{color:#0969da;text-decoration:none}a:hover{text-decoration:underline}
+table{border-collapse:collapse;margin:8px 0 16px;width:100%}th,td{border:1px solid #d0d7de;padding:6px 10px;text-align:left;vertical-align:top}th{background:#f6f8fa}
+code{background:#f6f8fa;border-radius:4px;padding:1px 4px}h3{border-bottom:1px solid #d0d7de;padding-bottom:4px;margin-top:32px}
+.deprecated{text-decoration:line-through}nav{margin-bottom:16px}nav a{margin-right:12px}`
+
+type htmlRenderer struct{}
+
+func (r *htmlRenderer) ext() string {
+	return ".html"
+}
+
+func (r *htmlRenderer) languages(site *site) []byte {
+	buf := bytes.NewBuffer(nil)
+	r.begin(buf, "en", site.title)
+	_, _ = fmt.Fprintf(buf, "<h1>%s</h1>\n<ul>\n", html.EscapeString(site.title))
+	for _, lang := range site.langs {
+		_, _ = fmt.Fprintf(buf, "<li><a href=\"%s/index.html\">%s</a></li>\n", lang, label(lang, "language"))
+	}
+	buf.WriteString("</ul>\n")
+	r.end(buf)
+	return buf.Bytes()
+}
+
+func (r *htmlRenderer) index(site *site, lang string) []byte {
+	buf := bytes.NewBuffer(nil)
+	r.begin(buf, lang, site.title)
+	r.nav(buf, site, lang, "index.html")
+	_, _ = fmt.Fprintf(buf, "<h1>%s</h1>\n<h2>%s</h2>\n", html.EscapeString(site.title), label(lang, "services"))
+	buf.WriteString("<table>\n")
+	htmlRow(buf, "th", label(lang, "name"), label(lang, "title"), label(lang, "description"))
+	for _, service := range site.services {
+		htmlRow(buf, "td", fmt.Sprintf("<a href=\"%s.html\">%s</a>", site.page(service), html.EscapeString(service.Name)), htmlText(service.Title), htmlText(service.Description))
+	}
+	buf.WriteString("</table>\n")
+	_, _ = fmt.Fprintf(buf, "<h2>%s</h2>\n", label(lang, "catalog"))
+	items := site.catalog(lang)
+	if len(items) == 0 {
+		_, _ = fmt.Fprintf(buf, "<p>%s</p>\n", label(lang, "none"))
+	} else {
+		buf.WriteString("<table>\n")
+		htmlRow(buf, "th", label(lang, "error"), label(lang, "service"), label(lang, "fn"), label(lang, "description"))
+		for _, item := range items {
+			page := site.page(item.Service)
+			htmlRow(buf, "td",
+				"<code>"+html.EscapeString(item.Name)+"</code>",
+				fmt.Sprintf("<a href=\"%s.html\">%s</a>", page, html.EscapeString(item.Service.Name)),
+				fmt.Sprintf("<a href=\"%s.html#fn-%s\">%s</a>", page, html.EscapeString(item.Fn.Name), html.EscapeString(item.Fn.Name)),
+				htmlText(item.Description),
+			)
+		}
+		buf.WriteString("</table>\n")
+	}
+	r.end(buf)
+	return buf.Bytes()
+}
+
+func (r *htmlRenderer) service(site *site, lang string, service *sources.Service) []byte {
+	buf := bytes.NewBuffer(nil)
+	r.begin(buf, lang, service.Name+" - "+site.title)
+	r.nav(buf, site, lang, site.page(service)+".html")
+	_, _ = fmt.Fprintf(buf, "<h1>%s</h1>\n", html.EscapeString(heading(service.Name, service.Title)))
+	if service.Description != "" {
+		_, _ = fmt.Fprintf(buf, "<p>%s</p>\n", htmlText(service.Description))
+	}
+	_, _ = fmt.Fprintf(buf, "<h2>%s</h2>\n<ul>\n", label(lang, "fns"))
+	for _, fn := range service.Fns {
+		_, _ = fmt.Fprintf(buf, "<li><a href=\"#fn-%s\">%s</a> %s</li>\n", html.EscapeString(fn.Name), html.EscapeString(fn.Name), html.EscapeString(fn.Title))
+	}
+	buf.WriteString("</ul>\n")
+	for _, fn := range service.Fns {
+		_, _ = fmt.Fprintf(buf, "<h3 id=\"fn-%s\">%s</h3>\n", html.EscapeString(fn.Name), html.EscapeString(heading(fn.Name, fn.Title)))
+		if fn.Description != "" {
+			_, _ = fmt.Fprintf(buf, "<p>%s</p>\n", htmlText(fn.Description))
+		}
+		_, _ = fmt.Fprintf(buf, "<p>%s: <code>POST /%s/%s</code></p>\n", label(lang, "path"), html.EscapeString(service.Name), html.EscapeString(fn.Name))
+		flags := site.flags(fn, lang)
+		headers, values := make([]string, 0, len(flags)), make([]string, 0, len(flags))
+		for _, flag := range flags {
+			headers, values = append(headers, flag[0]), append(values, flag[1])
+		}
+		buf.WriteString("<table>\n")
+		htmlRow(buf, "th", headers...)
+		htmlRow(buf, "td", values...)
+		buf.WriteString("</table>\n")
+		r.typeSection(buf, site, lang, label(lang, "argument"), fn.Argument)
+		r.typeSection(buf, site, lang, label(lang, "result"), fn.Result)
+		_, _ = fmt.Fprintf(buf, "<h4>%s</h4>\n", label(lang, "errors"))
+		if len(fn.Errors) == 0 {
+			_, _ = fmt.Fprintf(buf, "<p>%s</p>\n", label(lang, "none"))
+			continue
+		}
+		buf.WriteString("<table>\n")
+		htmlRow(buf, "th", label(lang, "error"), label(lang, "description"))
+		for _, e := range fn.Errors {
+			htmlRow(buf, "td", "<code>"+html.EscapeString(e.Name)+"</code>", htmlText(text(e.Descriptions, lang)))
+		}
+		buf.WriteString("</table>\n")
+	}
+	if types := site.types(service); len(types) > 0 {
+		_, _ = fmt.Fprintf(buf, "<h2>%s</h2>\n", label(lang, "types"))
+		for _, t := range types {
+			key := site.builder.Key(t)
+			_, _ = fmt.Fprintf(buf, "<h3 id=\"%s\">%s</h3>\n", anchor(key), html.EscapeString(heading(key, t.Title)))
+			if t.Description != "" {
+				_, _ = fmt.Fprintf(buf, "<p>%s</p>\n", htmlText(t.Description))
+			}
+			r.typeTable(buf, site, lang, t)
+		}
+	}
+	r.end(buf)
+	return buf.Bytes()
+}
+
+func (r *htmlRenderer) typeSection(buf *bytes.Buffer, site *site, lang string, title string, t *sources.Type) {
+	_, _ = fmt.Fprintf(buf, "<h4>%s</h4>\n", title)
+	if t == nil {
+		_, _ = fmt.Fprintf(buf, "<p>%s</p>\n", label(lang, "none"))
+		return
+	}
+	_, _ = fmt.Fprintf(buf, "<p>%s: %s</p>\n", label(lang, "type"), r.typeName(site, t))
+	r.typeTable(buf, site, lang, t)
+}
+
+// typeTable writes fields table of struct, other types are described by their names.
+func (r *htmlRenderer) typeTable(buf *bytes.Buffer, site *site, lang string, t *sources.Type) {
+	if t.Kind != sources.StructKind {
+		if t.Elem != nil {
+			_, _ = fmt.Fprintf(buf, "<p>%s: %s</p>\n", label(lang, "type"), r.typeName(site, &sources.Type{Kind: t.Kind, Elem: t.Elem}))
+		}
+		return
+	}
+	buf.WriteString("<table>\n")
+	htmlRow(buf, "th", label(lang, "field"), label(lang, "type"), label(lang, "required"), label(lang, "validate"), label(lang, "message"), label(lang, "description"))
+	for _, f := range site.fields(t, lang) {
+		key := "<code>" + html.EscapeString(f.Key) + "</code>"
+		if f.Deprecated {
+			key = "<span class=\"deprecated\">" + key + "</span>"
+		}
+		validate := ""
+		if f.Validate != "" {
+			validate = "<code>" + html.EscapeString(f.Validate) + "</code>"
+		}
+		htmlRow(buf, "td", key, r.typeName(site, f.Type), yesNo(f.Required, lang), validate, htmlText(f.Message), htmlText(f.Description))
+	}
+	buf.WriteString("</table>\n")
+}
+
+func (r *htmlRenderer) typeName(site *site, t *sources.Type) string {
+	return site.typeName(t, func(key string) string {
+		return fmt.Sprintf("<a href=\"#%s\">%s</a>", anchor(key), html.EscapeString(key))
+	})
+}
+
+func (r *htmlRenderer) begin(buf *bytes.Buffer, lang string, title string) {
+	_, _ = fmt.Fprintf(buf, "<!DOCTYPE html>\n<html lang=\"%s\">\n<head>\n<meta charset=\"utf-8\">\n", lang)
+	_, _ = fmt.Fprintf(buf, "<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n<title>%s</title>\n", html.EscapeString(title))
+	_, _ = fmt.Fprintf(buf, "<style>\n%s\n</style>\n</head>\n<body>\n", htmlStyle)
+}
+
+func (r *htmlRenderer) end(buf *bytes.Buffer) {
+	buf.WriteString("</body>\n</html>\n")
+}
+
+// nav writes links of services index and same page in other languages.
+func (r *htmlRenderer) nav(buf *bytes.Buffer, site *site, lang string, page string) {
+	_, _ = fmt.Fprintf(buf, "<nav><a href=\"index.html\">%s</a>", label(lang, "back"))
+	for _, other := range site.langs {
+		if other != lang {
+			_, _ = fmt.Fprintf(buf, "<a href=\"../%s/%s\">%s</a>", other, page, label(other, "language"))
+		}
+	}
+	buf.WriteString("</nav>\n")
+}
+
+func htmlRow(buf *bytes.Buffer, tag string, cells ...string) {
+	buf.WriteString("<tr>")
+	for _, cell := range cells {
+		_, _ = fmt.Fprintf(buf, "<%s>%s</%s>", tag, cell, tag)
+	}
+	buf.WriteString("</tr>\n")
+}
+
+// htmlText escapes text and keeps line breaks.
+func htmlText(s string) string {
+	return strings.ReplaceAll(html.EscapeString(strings.TrimSpace(s)), "\n", "<br>")
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package docs
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/aacfactory/fnc/sources"
+	"strings"
+)
+
+var markdownCommand = newSiteCommand("md", "fnc docs md --out ./docs {project path}", &markdown{})
+
+type markdown struct{}
+
+func (r *markdown) ext() string {
+	return ".md"
+}
+
+func (r *markdown) languages(site *site) []byte {
+	buf := bytes.NewBuffer(nil)
+	_, _ = fmt.Fprintf(buf, "# %s\n\n", site.title)
+	for _, lang := range site.langs {
+		_, _ = fmt.Fprintf(buf, "- [%s](%s/index.md)\n", label(lang, "language"), lang)
+	}
+	return buf.Bytes()
+}
+
+func (r *markdown) index(site *site, lang string) []byte {
+	buf := bytes.NewBuffer(nil)
+	_, _ = fmt.Fprintf(buf, "# %s\n\n", site.title)
+	_, _ = fmt.Fprintf(buf, "## %s\n\n", label(lang, "services"))
+	mdRow(buf, label(lang, "name"), label(lang, "title"), label(lang, "description"))
+	mdRow(buf, "---", "---", "---")
+	for _, service := range site.services {
+		mdRow(buf, fmt.Sprintf("[%s](%s.md)", service.Name, site.page(service)), mdCell(service.Title), mdCell(service.Description))
+	}
+	_, _ = fmt.Fprintf(buf, "\n## %s\n\n", label(lang, "catalog"))
+	items := site.catalog(lang)
+	if len(items) == 0 {
+		_, _ = fmt.Fprintf(buf, "%s\n", label(lang, "none"))
+		return buf.Bytes()
+	}
+	mdRow(buf, label(lang, "error"), label(lang, "service"), label(lang, "fn"), label(lang, "description"))
+	mdRow(buf, "---", "---", "---", "---")
+	for _, item := range items {
+		mdRow(buf, "`"+item.Name+"`", fmt.Sprintf("[%s](%s.md)", item.Service.Name, site.page(item.Service)), fmt.Sprintf("[%s](%s.md#fn-%s)", item.Fn.Name, site.page(item.Service), item.Fn.Name), mdCell(item.Description))
+	}
+	return buf.Bytes()
+}
+
+func (r *markdown) service(site *site, lang string, service *sources.Service) []byte {
+	buf := bytes.NewBuffer(nil)
+	_, _ = fmt.Fprintf(buf, "# %s\n\n[%s](index.md)\n\n", heading(service.Name, service.Title), label(lang, "back"))
+	if service.Description != "" {
+		_, _ = fmt.Fprintf(buf, "%s\n\n", service.Description)
+	}
+	_, _ = fmt.Fprintf(buf, "## %s\n\n", label(lang, "fns"))
+	for _, fn := range service.Fns {
+		_, _ = fmt.Fprintf(buf, "- [%s](#fn-%s) %s\n", fn.Name, fn.Name, fn.Title)
+	}
+	for _, fn := range service.Fns {
+		_, _ = fmt.Fprintf(buf, "\n<a id=\"fn-%s\"></a>\n\n### %s\n\n", fn.Name, heading(fn.Name, fn.Title))
+		if fn.Description != "" {
+			_, _ = fmt.Fprintf(buf, "%s\n\n", fn.Description)
+		}
+		_, _ = fmt.Fprintf(buf, "%s: `POST /%s/%s`\n\n", label(lang, "path"), service.Name, fn.Name)
+		flags := site.flags(fn, lang)
+		headers, values, line := make([]string, 0, len(flags)), make([]string, 0, len(flags)), make([]string, 0, len(flags))
+		for _, flag := range flags {
+			headers, values, line = append(headers, flag[0]), append(values, flag[1]), append(line, "---")
+		}
+		mdRow(buf, headers...)
+		mdRow(buf, line...)
+		mdRow(buf, values...)
+		r.typeSection(buf, site, lang, label(lang, "argument"), fn.Argument)
+		r.typeSection(buf, site, lang, label(lang, "result"), fn.Result)
+		_, _ = fmt.Fprintf(buf, "\n#### %s\n\n", label(lang, "errors"))
+		if len(fn.Errors) == 0 {
+			_, _ = fmt.Fprintf(buf, "%s\n", label(lang, "none"))
+			continue
+		}
+		mdRow(buf, label(lang, "error"), label(lang, "description"))
+		mdRow(buf, "---", "---")
+		for _, e := range fn.Errors {
+			mdRow(buf, "`"+e.Name+"`", mdCell(text(e.Descriptions, lang)))
+		}
+	}
+	if types := site.types(service); len(types) > 0 {
+		_, _ = fmt.Fprintf(buf, "\n## %s\n", label(lang, "types"))
+		for _, t := range types {
+			key := site.builder.Key(t)
+			_, _ = fmt.Fprintf(buf, "\n<a id=\"%s\"></a>\n\n### %s\n\n", anchor(key), heading(key, t.Title))
+			if t.Description != "" {
+				_, _ = fmt.Fprintf(buf, "%s\n\n", t.Description)
+			}
+			r.typeTable(buf, site, lang, t)
+		}
+	}
+	return buf.Bytes()
+}
+
+func (r *markdown) typeSection(buf *bytes.Buffer, site *site, lang string, title string, t *sources.Type) {
+	_, _ = fmt.Fprintf(buf, "\n#### %s\n\n", title)
+	if t == nil {
+		_, _ = fmt.Fprintf(buf, "%s\n", label(lang, "none"))
+		return
+	}
+	_, _ = fmt.Fprintf(buf, "%s: %s\n\n", label(lang, "type"), r.typeName(site, t))
+	r.typeTable(buf, site, lang, t)
+}
+
+// typeTable writes fields table of struct, other types are described by their names.
+func (r *markdown) typeTable(buf *bytes.Buffer, site *site, lang string, t *sources.Type) {
+	if t.Kind != sources.StructKind {
+		if t.Elem != nil {
+			_, _ = fmt.Fprintf(buf, "%s: %s\n", label(lang, "type"), r.typeName(site, &sources.Type{Kind: t.Kind, Elem: t.Elem}))
+		}
+		return
+	}
+	mdRow(buf, label(lang, "field"), label(lang, "type"), label(lang, "required"), label(lang, "validate"), label(lang, "message"), label(lang, "description"))
+	mdRow(buf, "---", "---", "---", "---", "---", "---")
+	for _, f := range site.fields(t, lang) {
+		key := "`" + f.Key + "`"
+		if f.Deprecated {
+			key = "~~" + key + "~~"
+		}
+		validate := ""
+		if f.Validate != "" {
+			validate = "`" + mdCell(f.Validate) + "`"
+		}
+		mdRow(buf, key, r.typeName(site, f.Type), yesNo(f.Required, lang), validate, mdCell(f.Message), mdCell(f.Description))
+	}
+}
+
+func (r *markdown) typeName(site *site, t *sources.Type) string {
+	return site.typeName(t, func(key string) string {
+		return fmt.Sprintf("[%s](#%s)", key, anchor(key))
+	})
+}
+
+func mdRow(buf *bytes.Buffer, cells ...string) {
+	_, _ = fmt.Fprintf(buf, "| %s |\n", strings.Join(cells, " | "))
+}
+
+// mdCell escapes text in table cell.
+func mdCell(s string) string {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "|", "\\|")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
@@ -0,0 +1,382 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package docs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/fnc/schema"
+	"github.com/aacfactory/fnc/sources"
+	"github.com/goccy/go-yaml"
+	"github.com/urfave/cli/v2"
+	"path/filepath"
+	"strings"
+)
+
+var openapiCommand = &cli.Command{
+	Name: "openapi",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "out",
+			Aliases:  []string{"o"},
+			Value:    "openapi.json",
+			Usage:    "output file, it is written in yaml when the ext is .yaml or .yml",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "title",
+			Usage:    "title of document, default is module path of project",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "version",
+			Value:    "1.0.0",
+			Usage:    "version of document",
+			Required: false,
+		},
+		&cli.StringSliceFlag{
+			Name:     "server",
+			Usage:    "url of server, repeatable",
+			Required: false,
+		},
+		&cli.BoolFlag{
+			Name:     "internal",
+			Usage:    "include internal services and fns",
+			Required: false,
+		},
+	},
+	Usage:       "fnc docs openapi --out openapi.yaml {project path}",
+	Description: "generate openapi 3.1 document of services and fns",
+	Action: func(ctx *cli.Context) (err error) {
+		project, loadErr := load(ctx)
+		if loadErr != nil {
+			err = errors.Warning("fnc: generate openapi failed").WithCause(loadErr)
+			return
+		}
+		doc := newOpenapi(project, openapiOptions{
+			Title:    ctx.String("title"),
+			Version:  ctx.String("version"),
+			Servers:  ctx.StringSlice("server"),
+			Internal: ctx.Bool("internal"),
+		})
+		p, encodeErr := json.MarshalIndent(doc, "", "  ")
+		if encodeErr != nil {
+			err = errors.Warning("fnc: generate openapi failed").WithCause(encodeErr)
+			return
+		}
+		out := ctx.String("out")
+		switch strings.ToLower(filepath.Ext(out)) {
+		case ".yaml", ".yml":
+			p, encodeErr = yaml.JSONToYAML(p)
+			if encodeErr != nil {
+				err = errors.Warning("fnc: generate openapi failed").WithCause(encodeErr)
+				return
+			}
+		}
+		if err = write(out, p); err != nil {
+			err = errors.Warning("fnc: generate openapi failed").WithCause(err)
+			return
+		}
+		fmt.Println(fmt.Sprintf("fnc: openapi document has been written into %s", out))
+		return
+	},
+}
+
+type openapiOptions struct {
+	Title    string
+	Version  string
+	Servers  []string
+	Internal bool
+}
+
+type openapi struct {
+	Openapi    string            `json:"openapi"`
+	Info       openapiInfo       `json:"info"`
+	Servers    []openapiServer   `json:"servers,omitempty"`
+	Tags       []openapiTag      `json:"tags,omitempty"`
+	Paths      openapiPaths      `json:"paths"`
+	Components openapiComponents `json:"components"`
+}
+
+type openapiInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openapiServer struct {
+	Url string `json:"url"`
+}
+
+type openapiTag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type openapiPath struct {
+	Path string
+	Item *openapiPathItem
+}
+
+// openapiPaths keeps the order of paths in json.
+type openapiPaths []openapiPath
+
+func (paths openapiPaths) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBufferString("{")
+	for i, path := range paths {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(path.Path)
+		buf.Write(key)
+		buf.WriteByte(':')
+		p, err := json.Marshal(path.Item)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(p)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type openapiPathItem struct {
+	Post *openapiOperation `json:"post"`
+}
+
+type openapiOperation struct {
+	OperationId string                `json:"operationId"`
+	Tags        []string              `json:"tags"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	RequestBody *openapiRequestBody   `json:"requestBody,omitempty"`
+	Responses   openapiResponses      `json:"responses"`
+	Timeout     string                `json:"x-fns-timeout,omitempty"`
+	Barrier     bool                  `json:"x-fns-barrier,omitempty"`
+	Permission  bool                  `json:"x-fns-permission,omitempty"`
+	Errors      []openapiError        `json:"x-fns-errors,omitempty"`
+}
+
+type openapiRequestBody struct {
+	Required bool                      `json:"required"`
+	Content  map[string]openapiContent `json:"content"`
+}
+
+type openapiContent struct {
+	Schema *schema.Schema `json:"schema"`
+}
+
+type openapiResponse struct {
+	Code        string                    `json:"-"`
+	Description string                    `json:"description"`
+	Content     map[string]openapiContent `json:"content,omitempty"`
+}
+
+// openapiResponses keeps the order of responses in json.
+type openapiResponses []*openapiResponse
+
+func (responses openapiResponses) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBufferString("{")
+	for i, response := range responses {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(response.Code)
+		buf.Write(key)
+		buf.WriteByte(':')
+		p, err := json.Marshal(response)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(p)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// openapiError is an error in @errors of fn, descriptions are keyed by language.
+type openapiError struct {
+	Name         string            `json:"name"`
+	Descriptions map[string]string `json:"descriptions,omitempty"`
+}
+
+type openapiComponents struct {
+	Schemas         schema.Properties                `json:"schemas"`
+	SecuritySchemes map[string]openapiSecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type openapiSecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+const (
+	codeErrorSchema  = "CodeError"
+	authorizationKey = "authorization"
+)
+
+func newOpenapi(project *sources.Project, opt openapiOptions) (doc *openapi) {
+	doc = &openapi{
+		Openapi: "3.1.0",
+		Info: openapiInfo{
+			Title:   opt.Title,
+			Version: opt.Version,
+		},
+		Servers: make([]openapiServer, 0, len(opt.Servers)),
+		Tags:    make([]openapiTag, 0, len(project.Services)),
+		Paths:   make(openapiPaths, 0, 1),
+	}
+	if doc.Info.Title == "" {
+		doc.Info.Title = project.Path
+	}
+	for _, server := range opt.Servers {
+		doc.Servers = append(doc.Servers, openapiServer{Url: server})
+	}
+	builder := schema.NewBuilder("#/components/schemas/")
+	authorization := false
+	for _, service := range project.Services {
+		if service.Internal && !opt.Internal {
+			continue
+		}
+		doc.Tags = append(doc.Tags, openapiTag{
+			Name:        service.Name,
+			Description: strings.TrimSpace(service.Title + "\n\n" + service.Description),
+		})
+		for _, fn := range service.Fns {
+			if fn.Internal && !opt.Internal {
+				continue
+			}
+			operation := newOperation(builder, service, fn)
+			if fn.Authorization {
+				authorization = true
+			}
+			doc.Paths = append(doc.Paths, openapiPath{
+				Path: fmt.Sprintf("/%s/%s", service.Name, fn.Name),
+				Item: &openapiPathItem{Post: operation},
+			})
+		}
+	}
+	doc.Components.Schemas = append(builder.Definitions(), schema.Property{Name: codeErrorSchema, Schema: codeError()})
+	if authorization {
+		doc.Components.SecuritySchemes = map[string]openapiSecurityScheme{
+			authorizationKey: {Type: "http", Scheme: "bearer"},
+		}
+	}
+	return
+}
+
+func newOperation(builder *schema.Builder, service *sources.Service, fn *sources.Fn) (operation *openapiOperation) {
+	operation = &openapiOperation{
+		OperationId: fmt.Sprintf("%s.%s", service.Name, fn.Name),
+		Tags:        []string{service.Name},
+		Summary:     fn.Title,
+		Description: fn.Description,
+		Deprecated:  fn.Deprecated,
+		Responses:   make(openapiResponses, 0, 4),
+		Barrier:     fn.Barrier,
+		Permission:  fn.Permission,
+	}
+	if fn.Timeout > 0 {
+		operation.Timeout = fn.Timeout.String()
+	}
+	if fn.Authorization {
+		operation.Security = []map[string][]string{{authorizationKey: {}}}
+	}
+	if fn.Argument != nil {
+		operation.RequestBody = &openapiRequestBody{
+			Required: true,
+			Content:  jsonContent(builder.Schema(fn.Argument)),
+		}
+	}
+	succeed := &openapiResponse{Code: "200", Description: "succeed"}
+	if fn.Result != nil {
+		succeed.Content = jsonContent(builder.Schema(fn.Result))
+	}
+	operation.Responses = append(operation.Responses, succeed)
+	errorContent := jsonContent(&schema.Schema{Ref: "#/components/schemas/" + codeErrorSchema})
+	if fn.Argument != nil {
+		operation.Responses = append(operation.Responses, &openapiResponse{Code: "400", Description: "argument is invalid", Content: errorContent})
+	}
+	if fn.Authorization {
+		operation.Responses = append(operation.Responses, &openapiResponse{Code: "401", Description: "unauthorized", Content: errorContent})
+	}
+	if fn.Permission {
+		operation.Responses = append(operation.Responses, &openapiResponse{Code: "403", Description: "forbidden", Content: errorContent})
+	}
+	if fn.Timeout > 0 {
+		operation.Responses = append(operation.Responses, &openapiResponse{Code: "408", Description: fmt.Sprintf("timeout, fn is canceled after %s", fn.Timeout), Content: errorContent})
+	}
+	description := "failed"
+	if len(fn.Errors) > 0 {
+		// names of @errors are listed in description and x-fns-errors, the status code is the code of error
+		lines := make([]string, 0, len(fn.Errors)+1)
+		lines = append(lines, "failed, name of error is one of:", "")
+		operation.Errors = make([]openapiError, 0, len(fn.Errors))
+		for _, e := range fn.Errors {
+			item := openapiError{Name: e.Name}
+			texts := make([]string, 0, len(e.Descriptions))
+			if len(e.Descriptions) > 0 {
+				item.Descriptions = make(map[string]string)
+			}
+			for _, d := range e.Descriptions {
+				item.Descriptions[d.Lang] = d.Text
+				texts = append(texts, fmt.Sprintf("%s: %s", d.Lang, d.Text))
+			}
+			operation.Errors = append(operation.Errors, item)
+			line := fmt.Sprintf("- `%s`", e.Name)
+			if len(texts) > 0 {
+				line = line + " " + strings.Join(texts, "; ")
+			}
+			lines = append(lines, line)
+		}
+		description = strings.Join(lines, "\n")
+	}
+	operation.Responses = append(operation.Responses, &openapiResponse{Code: "default", Description: description, Content: errorContent})
+	return
+}
+
+func jsonContent(s *schema.Schema) map[string]openapiContent {
+	return map[string]openapiContent{"application/json": {Schema: s}}
+}
+
+// codeError returns the schema of errors.CodeError in json.
+func codeError() *schema.Schema {
+	str := func(description string) *schema.Schema {
+		return &schema.Schema{Type: "string", Description: description}
+	}
+	return &schema.Schema{
+		Type:        "object",
+		Description: "error of fns",
+		Properties: schema.Properties{
+			{Name: "id", Schema: str("id of error")},
+			{Name: "code", Schema: &schema.Schema{Type: "integer", Description: "code of error, it is the status code of response"}},
+			{Name: "name", Schema: str("name of error")},
+			{Name: "message", Schema: str("message of error")},
+			{Name: "meta", Schema: &schema.Schema{Type: "object", AdditionalProperties: &schema.Schema{Type: "string"}}},
+			{Name: "stacktrace", Schema: &schema.Schema{Type: "object", Properties: schema.Properties{
+				{Name: "fn", Schema: str("")},
+				{Name: "file", Schema: str("")},
+				{Name: "line", Schema: &schema.Schema{Type: "integer"}},
+			}}},
+			{Name: "cause", Schema: &schema.Schema{Ref: "#/components/schemas/" + codeErrorSchema}},
+		},
+		Required: []string{"code", "name", "message"},
+	}
+}
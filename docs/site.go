@@ -0,0 +1,379 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package docs
+
+import (
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/fnc/schema"
+	"github.com/aacfactory/fnc/sources"
+	"github.com/urfave/cli/v2"
+	"path/filepath"
+	"strings"
+)
+
+// renderer renders pages of site in a format.
+type renderer interface {
+	// ext is the ext of page files, e.g. .md
+	ext() string
+	// languages renders the root page which links to index pages of languages.
+	languages(site *site) []byte
+	// index renders the page of services and error catalog.
+	index(site *site, lang string) []byte
+	// service renders the page of service, it contains fns and types.
+	service(site *site, lang string, service *sources.Service) []byte
+}
+
+func newSiteCommand(name string, usage string, r renderer) *cli.Command {
+	return &cli.Command{
+		Name: name,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "out",
+				Aliases:  []string{"o"},
+				Value:    "docs",
+				Usage:    "output dir, pages of a language are written into the dir named by language",
+				Required: false,
+			},
+			&cli.StringSliceFlag{
+				Name:     "lang",
+				Value:    cli.NewStringSlice("zh", "en"),
+				Usage:    "languages, zh or en, repeatable",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "title",
+				Usage:    "title of site, default is module path of project",
+				Required: false,
+			},
+			&cli.BoolFlag{
+				Name:     "internal",
+				Usage:    "include internal services and fns",
+				Required: false,
+			},
+		},
+		Usage:       usage,
+		Description: "generate api reference of services and fns",
+		Action: func(ctx *cli.Context) (err error) {
+			project, loadErr := load(ctx)
+			if loadErr != nil {
+				err = errors.Warning("fnc: generate docs failed").WithCause(loadErr)
+				return
+			}
+			langs := ctx.StringSlice("lang")
+			for _, lang := range langs {
+				if _, has := labels[lang]; !has {
+					err = errors.Warning("fnc: generate docs failed").WithCause(errors.Warning("language is not supported")).WithMeta("lang", lang)
+					return
+				}
+			}
+			site := newSite(project, ctx.String("title"), langs, ctx.Bool("internal"))
+			out := ctx.String("out")
+			if err = write(filepath.Join(out, "index"+r.ext()), r.languages(site)); err != nil {
+				err = errors.Warning("fnc: generate docs failed").WithCause(err)
+				return
+			}
+			for _, lang := range langs {
+				if err = write(filepath.Join(out, lang, "index"+r.ext()), r.index(site, lang)); err != nil {
+					err = errors.Warning("fnc: generate docs failed").WithCause(err)
+					return
+				}
+				for _, service := range site.services {
+					if err = write(filepath.Join(out, lang, site.page(service)+r.ext()), r.service(site, lang, service)); err != nil {
+						err = errors.Warning("fnc: generate docs failed").WithCause(err)
+						return
+					}
+				}
+			}
+			fmt.Println(fmt.Sprintf("fnc: docs have been written into %s", out))
+			return
+		},
+	}
+}
+
+type site struct {
+	title    string
+	langs    []string
+	services []*sources.Service
+	builder  *schema.Builder
+}
+
+func newSite(project *sources.Project, title string, langs []string, internal bool) *site {
+	if title == "" {
+		title = project.Path
+	}
+	services := make([]*sources.Service, 0, len(project.Services))
+	roots := make([]*sources.Type, 0, 1)
+	for _, service := range project.Services {
+		if service.Internal && !internal {
+			continue
+		}
+		fns := make([]*sources.Fn, 0, len(service.Fns))
+		for _, fn := range service.Fns {
+			if fn.Internal && !internal {
+				continue
+			}
+			fns = append(fns, fn)
+			roots = append(roots, fn.Argument, fn.Result)
+		}
+		copied := *service
+		copied.Fns = fns
+		services = append(services, &copied)
+	}
+	builder := schema.NewBuilder("")
+	// assign keys of all types, so names are same in all pages
+	builder.Refs(roots...)
+	return &site{
+		title:    title,
+		langs:    langs,
+		services: services,
+		builder:  builder,
+	}
+}
+
+// page returns the page name of service.
+func (site *site) page(service *sources.Service) string {
+	if service.Name == "index" {
+		return "index_service"
+	}
+	return service.Name
+}
+
+// types returns named types which are used by fns of service.
+func (site *site) types(service *sources.Service) []*sources.Type {
+	roots := make([]*sources.Type, 0, 1)
+	for _, fn := range service.Fns {
+		roots = append(roots, fn.Argument, fn.Result)
+	}
+	return site.builder.Refs(roots...)
+}
+
+// typeName returns the display name of t, referenced types are linked by link.
+func (site *site) typeName(t *sources.Type, link func(key string) string) string {
+	if schema.Referenced(t) {
+		return link(site.builder.Key(t))
+	}
+	switch t.Kind {
+	case sources.ArrayKind:
+		return site.typeName(t.Elem, link) + "[]"
+	case sources.MapKind:
+		return "map[string]" + site.typeName(t.Elem, link)
+	case sources.StructKind:
+		return "object"
+	case sources.IntKind, sources.UintKind, sources.FloatKind:
+		if t.Bits > 0 {
+			return fmt.Sprintf("%s%d", t.Kind, t.Bits)
+		}
+	}
+	return string(t.Kind)
+}
+
+// heading returns the heading of name, title is added when it is not same as name.
+func heading(name string, title string) string {
+	if title == "" || strings.EqualFold(name, title) {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, title)
+}
+
+// anchor returns the anchor of type key in page, it is case-sensitive, so keys which differ by case only have different anchors.
+func anchor(key string) string {
+	return "type-" + key
+}
+
+// field is a row of fields table.
+type field struct {
+	Key         string
+	Type        *sources.Type
+	Required    bool
+	Validate    string
+	Message     string
+	Description string
+	Deprecated  bool
+}
+
+// fields returns rows of struct t, message is the validate message in lang.
+// fields of anonymous structs are not referenced, so they follow their parent field with keys joined by dot,
+// e.g. items[].name for an array of anonymous structs and attrs{}.name for a map.
+func (site *site) fields(t *sources.Type, lang string) (fields []field) {
+	fields = make([]field, 0, len(t.Fields))
+	site.appendFields(&fields, t, "", lang)
+	return
+}
+
+func (site *site) appendFields(fields *[]field, t *sources.Type, prefix string, lang string) {
+	for _, f := range t.Fields {
+		message := text(f.ValidateI18n, lang)
+		if message == "" {
+			message = f.ValidateMessage
+		}
+		key := prefix + f.Key
+		*fields = append(*fields, field{
+			Key:         key,
+			Type:        f.Type,
+			Required:    f.Required(),
+			Validate:    f.Validate,
+			Message:     message,
+			Description: strings.TrimSpace(f.Title + "\n" + f.Description),
+			Deprecated:  f.Deprecated,
+		})
+		elem := f.Type
+		for !schema.Referenced(elem) && elem.Elem != nil && (elem.Kind == sources.ArrayKind || elem.Kind == sources.MapKind) {
+			if elem.Kind == sources.ArrayKind {
+				key = key + "[]"
+			} else {
+				key = key + "{}"
+			}
+			elem = elem.Elem
+		}
+		if elem.Kind == sources.StructKind && !elem.Named() {
+			site.appendFields(fields, elem, key+".", lang)
+		}
+	}
+}
+
+// flags returns label and value pairs of fn.
+func (site *site) flags(fn *sources.Fn, lang string) [][2]string {
+	timeout := "-"
+	if fn.Timeout > 0 {
+		timeout = fn.Timeout.String()
+	}
+	return [][2]string{
+		{label(lang, "timeout"), timeout},
+		{label(lang, "barrier"), yesNo(fn.Barrier, lang)},
+		{label(lang, "authorization"), yesNo(fn.Authorization, lang)},
+		{label(lang, "permission"), yesNo(fn.Permission, lang)},
+		{label(lang, "internal"), yesNo(fn.Internal, lang)},
+		{label(lang, "deprecated"), yesNo(fn.Deprecated, lang)},
+	}
+}
+
+// catalogItem is a row of error catalog.
+type catalogItem struct {
+	Service     *sources.Service
+	Fn          *sources.Fn
+	Name        string
+	Description string
+}
+
+func (site *site) catalog(lang string) (items []catalogItem) {
+	items = make([]catalogItem, 0, 1)
+	for _, service := range site.services {
+		for _, fn := range service.Fns {
+			for _, e := range fn.Errors {
+				items = append(items, catalogItem{
+					Service:     service,
+					Fn:          fn,
+					Name:        e.Name,
+					Description: text(e.Descriptions, lang),
+				})
+			}
+		}
+	}
+	return
+}
+
+// text returns the text in lang, the first text is returned when lang is not found.
+func text(items []sources.I18n, lang string) string {
+	for _, item := range items {
+		if item.Lang == lang {
+			return item.Text
+		}
+	}
+	if len(items) > 0 {
+		return items[0].Text
+	}
+	return ""
+}
+
+func yesNo(v bool, lang string) string {
+	if v {
+		return label(lang, "yes")
+	}
+	return label(lang, "no")
+}
+
+var labels = map[string]map[string]string{
+	"en": {
+		"language":      "English",
+		"services":      "Services",
+		"service":       "Service",
+		"fns":           "Fns",
+		"fn":            "Fn",
+		"name":          "Name",
+		"title":         "Title",
+		"description":   "Description",
+		"path":          "Path",
+		"timeout":       "Timeout",
+		"barrier":       "Barrier",
+		"authorization": "Authorization",
+		"permission":    "Permission",
+		"internal":      "Internal",
+		"deprecated":    "Deprecated",
+		"argument":      "Argument",
+		"result":        "Result",
+		"types":         "Types",
+		"field":         "Field",
+		"type":          "Type",
+		"required":      "Required",
+		"validate":      "Validation",
+		"message":       "Validation message",
+		"errors":        "Errors",
+		"error":         "Error",
+		"catalog":       "Error catalog",
+		"none":          "None",
+		"yes":           "Yes",
+		"no":            "No",
+		"back":          "Back to services",
+	},
+	"zh": {
+		"language":      "中文",
+		"services":      "服务",
+		"service":       "服务",
+		"fns":           "函数",
+		"fn":            "函数",
+		"name":          "名称",
+		"title":         "标题",
+		"description":   "描述",
+		"path":          "路径",
+		"timeout":       "超时",
+		"barrier":       "屏障",
+		"authorization": "身份验证",
+		"permission":    "权限验证",
+		"internal":      "内部",
+		"deprecated":    "已废弃",
+		"argument":      "参数",
+		"result":        "结果",
+		"types":         "类型",
+		"field":         "字段",
+		"type":          "类型",
+		"required":      "必填",
+		"validate":      "校验规则",
+		"message":       "校验提示",
+		"errors":        "错误",
+		"error":         "错误",
+		"catalog":       "错误目录",
+		"none":          "无",
+		"yes":           "是",
+		"no":            "否",
+		"back":          "返回服务列表",
+	},
+}
+
+func label(lang string, key string) string {
+	return labels[lang][key]
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package docs
+
+import (
+	"github.com/aacfactory/fnc/sources"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const siteTestFns = `package users
+
+import (
+	"context"
+	"github.com/aacfactory/errors"
+)
+
+type User struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+type user struct {
+	Id string ` + "`json:\"id\"`" + `
+}
+
+type GetArgument struct {
+	Items []struct {
+		Name string ` + "`json:\"name\" validate:\"required\"`" + `
+		Tags map[string]struct {
+			Value string ` + "`json:\"value\"`" + `
+		} ` + "`json:\"tags\"`" + `
+	} ` + "`json:\"items\"`" + `
+	Owner User ` + "`json:\"owner\"`" + `
+	Raw   user ` + "`json:\"raw\"`" + `
+}
+
+// get
+// @fn get
+func get(ctx context.Context, argument GetArgument) (result *User, err errors.CodeError) {
+	return
+}
+`
+
+func loadSiteTestProject(t *testing.T) *sources.Project {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":               "module example.com/project\n\ngo 1.20\n",
+		"modules/users/doc.go": "// Package users\n// @service users\npackage users\n",
+		"modules/users/get.go": siteTestFns,
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	project, err := sources.LoadValid(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return project
+}
+
+func TestSiteFields(t *testing.T) {
+	project := loadSiteTestProject(t)
+	site := newSite(project, "", []string{"en"}, false)
+	fn := site.services[0].Fns[0]
+	keys := make([]string, 0, 1)
+	for _, f := range site.fields(fn.Argument, "en") {
+		keys = append(keys, f.Key)
+	}
+	want := "items,items[].name,items[].tags,items[].tags{}.value,owner,raw"
+	if got := strings.Join(keys, ","); got != want {
+		t.Errorf("got fields %s, want %s", got, want)
+	}
+}
+
+func TestSiteAnchors(t *testing.T) {
+	project := loadSiteTestProject(t)
+	site := newSite(project, "", []string{"en"}, false)
+	page := string((&markdown{}).service(site, "en", site.services[0]))
+	for _, want := range []string{`<a id="type-User"></a>`, `<a id="type-user"></a>`, "[User](#type-User)", "[user](#type-user)", "| `items[].tags{}.value` |"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("%s is not found in page:\n%s", want, page)
+		}
+	}
+}
@@ -3,19 +3,20 @@ module github.com/aacfactory/fnc
 go 1.20
 
 require (
-	github.com/aacfactory/afssl v1.6.0
 	github.com/aacfactory/errors v1.13.4
 	github.com/aacfactory/forg v1.0.10
+	github.com/fatih/color v1.15.0
 	github.com/goccy/go-yaml v1.10.0
 	github.com/urfave/cli/v2 v2.25.0
+	github.com/youmark/pkcs8 v0.0.0-20201027041543-1326539a0a0a
 	golang.org/x/mod v0.9.0
+	software.sslmate.com/src/go-pkcs12 v0.5.0
 )
 
 require (
 	github.com/aacfactory/cases v1.1.0 // indirect
 	github.com/aacfactory/gcg v1.0.4 // indirect
 	github.com/cpuguy83/go-md2man/v2 v2.0.2 // indirect
-	github.com/fatih/color v1.15.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.18 // indirect
 	github.com/rs/xid v1.4.0 // indirect
@@ -25,9 +26,9 @@ require (
 	github.com/tidwall/pretty v1.2.1 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	github.com/xrash/smetrics v0.0.0-20201216005158-039620a65673 // indirect
-	golang.org/x/crypto v0.6.0 // indirect
+	golang.org/x/crypto v0.11.0 // indirect
 	golang.org/x/sync v0.1.0 // indirect
-	golang.org/x/sys v0.6.0 // indirect
+	golang.org/x/sys v0.10.0 // indirect
 	golang.org/x/tools v0.7.0 // indirect
 	golang.org/x/xerrors v0.0.0-20220907171357-04be3eba64a2 // indirect
 )
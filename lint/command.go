@@ -0,0 +1,97 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lint
+
+import (
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/fnc/sources"
+	"github.com/aacfactory/forg"
+	"github.com/urfave/cli/v2"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var Command = &cli.Command{
+	Name: "lint",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:      "work",
+			Aliases:   []string{"w"},
+			Usage:     "set workspace file path",
+			Required:  false,
+			EnvVars:   []string{"FNC_WORK"},
+			TakesFile: false,
+		},
+	},
+	Aliases:     nil,
+	Usage:       "fnc lint {project path}",
+	Description: "check annotations of services and fns",
+	ArgsUsage:   "",
+	Category:    "",
+	Action: func(ctx *cli.Context) (err error) {
+		projectDir := strings.TrimSpace(ctx.Args().First())
+		if projectDir == "" {
+			projectDir = "."
+		}
+		if !filepath.IsAbs(projectDir) {
+			projectDir, err = filepath.Abs(projectDir)
+			if err != nil {
+				err = errors.Warning("fnc: lint failed").WithCause(err).WithMeta("dir", projectDir)
+				return
+			}
+		}
+		projectDir = filepath.ToSlash(projectDir)
+		// forg drops invalid annotations, so it is used to make sure that the project can be loaded only
+		work := ctx.String("work")
+		if work != "" {
+			_, err = forg.Load(projectDir, forg.WithWorkspace(work))
+		} else {
+			_, err = forg.Load(projectDir)
+		}
+		if err != nil {
+			err = errors.Warning("fnc: lint failed").WithCause(err)
+			return
+		}
+		project, loadErr := sources.Load(projectDir)
+		if loadErr != nil {
+			err = errors.Warning("fnc: lint failed").WithCause(loadErr)
+			return
+		}
+		err = report(os.Stdout, project)
+		return
+	},
+}
+
+// report prints problems of project, it returns an exit error when there are problems.
+func report(w io.Writer, project *sources.Project) (err error) {
+	for _, problem := range project.Problems {
+		_, _ = fmt.Fprintln(w, problem)
+	}
+	if n := len(project.Problems); n > 0 {
+		err = cli.Exit(fmt.Sprintf("fnc: %d problems were found", n), 1)
+		return
+	}
+	fns := 0
+	for _, service := range project.Services {
+		fns += len(service.Fns)
+	}
+	_, _ = fmt.Fprintf(w, "fnc: %d services and %d fns are checked, no problem was found\n", len(project.Services), fns)
+	return
+}
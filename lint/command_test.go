@@ -0,0 +1,54 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lint
+
+import (
+	"bytes"
+	"github.com/aacfactory/fnc/sources"
+	"github.com/urfave/cli/v2"
+	"go/token"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	project := &sources.Project{
+		Services: []*sources.Service{{Name: "users", Fns: []*sources.Fn{{Name: "get"}}}},
+		Problems: sources.Problems{
+			{Pos: token.Position{Filename: "modules/users/get.go", Line: 9}, Message: "unknown annotation @timout, did you mean @timeout?"},
+			{Pos: token.Position{Filename: "modules/users/get.go", Line: 12}, Message: "@errors block is not terminated by <<<"},
+		},
+	}
+	out := bytes.NewBuffer(nil)
+	err := report(out, project)
+	want := "modules/users/get.go:9: unknown annotation @timout, did you mean @timeout?\nmodules/users/get.go:12: @errors block is not terminated by <<<\n"
+	if out.String() != want {
+		t.Errorf("got output\n%s\nwant\n%s", out.String(), want)
+	}
+	exit, ok := err.(cli.ExitCoder)
+	if !ok || exit.ExitCode() != 1 {
+		t.Errorf("got error %v, want exit code 1", err)
+	}
+
+	project.Problems = nil
+	out.Reset()
+	if err = report(out, project); err != nil {
+		t.Fatal(err)
+	}
+	if want = "fnc: 1 services and 1 fns are checked, no problem was found\n"; out.String() != want {
+		t.Errorf("got output %s, want %s", out.String(), want)
+	}
+}
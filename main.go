@@ -21,6 +21,10 @@ import (
 	"fmt"
 	"github.com/aacfactory/fnc/codes"
 	"github.com/aacfactory/fnc/create"
+	"github.com/aacfactory/fnc/docs"
+	"github.com/aacfactory/fnc/lint"
+	"github.com/aacfactory/fnc/schema"
+	"github.com/aacfactory/fnc/sdk"
 	"github.com/aacfactory/fnc/ssc"
 	"github.com/urfave/cli/v2"
 	"os"
@@ -60,10 +64,15 @@ func main() {
 	app.Commands = []*cli.Command{
 		create.Command,
 		codes.Command,
+		docs.Command,
+		lint.Command,
+		sdk.Command,
+		schema.Command,
 		ssc.Command,
 	}
 	if err := app.RunContext(context.Background(), os.Args); err != nil {
 		fmt.Println(fmt.Sprintf("%+v", err))
+		os.Exit(1)
 	}
 
 }
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/fnc/sources"
+	"github.com/urfave/cli/v2"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var Command = &cli.Command{
+	Name: "schema",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "out",
+			Aliases:  []string{"o"},
+			Value:    "schemas",
+			Usage:    "output dir",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "base-url",
+			Usage:    "base url of $id, e.g. https://example.com/schemas, $id is absent when it is empty",
+			Required: false,
+		},
+		&cli.BoolFlag{
+			Name:     "internal",
+			Usage:    "include types of internal services and fns",
+			Required: false,
+		},
+	},
+	Aliases:     nil,
+	Usage:       "fnc schema --out ./schemas {project path}",
+	Description: "generate json schema (draft 2020-12) files of arguments and results of fns",
+	ArgsUsage:   "",
+	Category:    "",
+	Action: func(ctx *cli.Context) (err error) {
+		projectDir := strings.TrimSpace(ctx.Args().First())
+		if projectDir == "" {
+			projectDir = "."
+		}
+		project, loadErr := sources.LoadValid(projectDir)
+		if loadErr != nil {
+			err = errors.Warning("fnc: generate schema failed").WithCause(loadErr)
+			return
+		}
+		out := ctx.String("out")
+		if err = os.MkdirAll(out, 0755); err != nil {
+			err = errors.Warning("fnc: generate schema failed").WithCause(err).WithMeta("dir", out)
+			return
+		}
+		baseURL := strings.TrimRight(ctx.String("base-url"), "/")
+		names := NewBuilder("")
+		roots := fnTypes(project, ctx.Bool("internal"))
+		for _, t := range roots {
+			names.Key(t)
+		}
+		for _, t := range roots {
+			filename := names.Key(t) + ".schema.json"
+			doc := NewBuilder("#/$defs/").Document(t)
+			if baseURL != "" {
+				doc.Id = baseURL + "/" + filename
+			}
+			p, encodeErr := json.MarshalIndent(doc, "", "  ")
+			if encodeErr != nil {
+				err = errors.Warning("fnc: generate schema failed").WithCause(encodeErr).WithMeta("type", t.Path+"."+t.Name)
+				return
+			}
+			if err = os.WriteFile(filepath.Join(out, filename), append(p, '\n'), 0644); err != nil {
+				err = errors.Warning("fnc: generate schema failed").WithCause(err).WithMeta("file", filename)
+				return
+			}
+			fmt.Println(fmt.Sprintf("fnc: %s has been written", filepath.ToSlash(filepath.Join(out, filename))))
+		}
+		return
+	},
+}
+
+// fnTypes returns argument and result types of fns, they are distinct and in order of services and fns.
+func fnTypes(project *sources.Project, internal bool) (types []*sources.Type) {
+	types = make([]*sources.Type, 0, 1)
+	visited := make(map[*sources.Type]bool)
+	for _, service := range project.Services {
+		if service.Internal && !internal {
+			continue
+		}
+		for _, fn := range service.Fns {
+			if fn.Internal && !internal {
+				continue
+			}
+			for _, t := range []*sources.Type{fn.Argument, fn.Result} {
+				if t == nil || !t.Named() || visited[t] {
+					continue
+				}
+				visited[t] = true
+				types = append(types, t)
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,456 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/aacfactory/fnc/sources"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// draft is the meta schema of documents.
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a json schema of draft 2020-12, it is also the schema object of openapi 3.1.
+type Schema struct {
+	Schema               string            `json:"$schema,omitempty"`
+	Id                   string            `json:"$id,omitempty"`
+	Ref                  string            `json:"$ref,omitempty"`
+	Type                 string            `json:"type,omitempty"`
+	Format               string            `json:"format,omitempty"`
+	Title                string            `json:"title,omitempty"`
+	Description          string            `json:"description,omitempty"`
+	Properties           Properties        `json:"properties,omitempty"`
+	Required             []string          `json:"required,omitempty"`
+	Items                *Schema           `json:"items,omitempty"`
+	AdditionalProperties *Schema           `json:"additionalProperties,omitempty"`
+	Enum                 []interface{}     `json:"enum,omitempty"`
+	MinLength            *int              `json:"minLength,omitempty"`
+	MaxLength            *int              `json:"maxLength,omitempty"`
+	Minimum              *float64          `json:"minimum,omitempty"`
+	Maximum              *float64          `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64          `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64          `json:"exclusiveMaximum,omitempty"`
+	MinItems             *int              `json:"minItems,omitempty"`
+	MaxItems             *int              `json:"maxItems,omitempty"`
+	UniqueItems          bool              `json:"uniqueItems,omitempty"`
+	Pattern              string            `json:"pattern,omitempty"`
+	Deprecated           bool              `json:"deprecated,omitempty"`
+	ValidateMessage      string            `json:"x-validate-message,omitempty"`
+	ValidateI18n         map[string]string `json:"x-validate-message-i18n,omitempty"`
+	Defs                 Properties        `json:"$defs,omitempty"`
+}
+
+// Property is a named schema.
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+// Properties keeps the order of properties in json.
+type Properties []Property
+
+func (properties Properties) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBufferString("{")
+	for i, property := range properties {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(property.Name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		p, err := json.Marshal(property.Schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(p)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Builder converts types into schemas, named structs are put into definitions and referenced by $ref.
+type Builder struct {
+	refPrefix string
+	keys      map[*sources.Type]string
+	// names are keyed by folded keys
+	names    map[string]*sources.Type
+	reserved map[string]bool
+	fold     func(key string) string
+	defs     map[string]*Schema
+	// root is the type of document, it is referenced by "#"
+	root *sources.Type
+}
+
+// NewBuilder returns a builder, refPrefix is "#/$defs/" for json schema or "#/components/schemas/" for openapi.
+func NewBuilder(refPrefix string) *Builder {
+	return &Builder{
+		refPrefix: refPrefix,
+		keys:      make(map[*sources.Type]string),
+		names:     make(map[string]*sources.Type),
+		reserved:  make(map[string]bool),
+		fold: func(key string) string {
+			return key
+		},
+		defs: make(map[string]*Schema),
+	}
+}
+
+// FoldKeys sets the func which folds keys before they are compared, e.g. strings.ToLower when keys are case-insensitive.
+// it must be called before keys are assigned.
+func (builder *Builder) FoldKeys(fold func(key string) string) {
+	builder.fold = fold
+}
+
+// Reserve reserves keys, named types whose names are reserved are prefixed by their package names.
+// it must be called before keys are assigned.
+func (builder *Builder) Reserve(keys ...string) {
+	for _, key := range keys {
+		builder.reserved[builder.fold(key)] = true
+	}
+}
+
+// Key returns the definition key of named type, keys of types which have same name are prefixed by their package names.
+func (builder *Builder) Key(t *sources.Type) string {
+	if key, has := builder.keys[t]; has {
+		return key
+	}
+	key := t.Name
+	if builder.taken(key, t) {
+		pkg := t.Path[strings.LastIndex(t.Path, "/")+1:]
+		key = strings.ToUpper(pkg[:1]) + pkg[1:] + t.Name
+		for i := 2; builder.taken(key, t); i++ {
+			key = fmt.Sprintf("%s%s%d", strings.ToUpper(pkg[:1])+pkg[1:], t.Name, i)
+		}
+	}
+	builder.keys[t] = key
+	builder.names[builder.fold(key)] = t
+	return key
+}
+
+// taken returns true when key is reserved or is used by other type.
+func (builder *Builder) taken(key string, t *sources.Type) bool {
+	folded := builder.fold(key)
+	if builder.reserved[folded] {
+		return true
+	}
+	prev, has := builder.names[folded]
+	return has && prev != t
+}
+
+// Definitions returns schemas of named types which are referenced, they are sorted by key.
+func (builder *Builder) Definitions() (defs Properties) {
+	keys := make([]string, 0, len(builder.defs))
+	for key := range builder.defs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	defs = make(Properties, 0, len(keys))
+	for _, key := range keys {
+		defs = append(defs, Property{Name: key, Schema: builder.defs[key]})
+	}
+	return
+}
+
+// Refs returns named types which are referenced by schemas of roots, they are sorted by key.
+func (builder *Builder) Refs(roots ...*sources.Type) (types []*sources.Type) {
+	types = make([]*sources.Type, 0, 1)
+	visited := make(map[*sources.Type]bool)
+	var visit func(t *sources.Type)
+	visit = func(t *sources.Type) {
+		if t == nil || visited[t] {
+			return
+		}
+		visited[t] = true
+		if Referenced(t) {
+			types = append(types, t)
+			// keys are assigned in visiting order, so conflicts are resolved deterministically
+			builder.Key(t)
+		}
+		visit(t.Elem)
+		for _, field := range t.Fields {
+			visit(field.Type)
+		}
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return builder.Key(types[i]) < builder.Key(types[j])
+	})
+	return
+}
+
+// Referenced returns true when schema of type is a definition which is referenced by $ref.
+func Referenced(t *sources.Type) bool {
+	return t.Named() && (t.Kind == sources.StructKind || t.Kind == sources.ArrayKind || t.Kind == sources.MapKind)
+}
+
+// Schema returns the schema of type, named structs and named arrays or maps are referenced.
+func (builder *Builder) Schema(t *sources.Type) *Schema {
+	if t == builder.root {
+		return &Schema{Ref: "#"}
+	}
+	if Referenced(t) {
+		key := builder.Key(t)
+		if _, has := builder.defs[key]; !has {
+			// placeholder for recursive types
+			builder.defs[key] = &Schema{}
+			*builder.defs[key] = *builder.Define(t)
+		}
+		return &Schema{Ref: builder.refPrefix + key}
+	}
+	return builder.Define(t)
+}
+
+// Document returns the json schema document of type, referenced types are in $defs, so refPrefix of builder must be "#/$defs/".
+func (builder *Builder) Document(t *sources.Type) (s *Schema) {
+	builder.root = t
+	s = builder.Define(t)
+	s.Schema = draft
+	s.Defs = builder.Definitions()
+	return
+}
+
+// Define returns the schema of type itself, it is not referenced.
+func (builder *Builder) Define(t *sources.Type) (s *Schema) {
+	s = &Schema{
+		Title:       t.Title,
+		Description: t.Description,
+	}
+	switch t.Kind {
+	case sources.StringKind:
+		s.Type = "string"
+	case sources.BoolKind:
+		s.Type = "boolean"
+	case sources.IntKind, sources.UintKind:
+		s.Type = "integer"
+		if t.Bits == 32 || t.Bits == 64 {
+			s.Format = fmt.Sprintf("int%d", t.Bits)
+		}
+		if t.Kind == sources.UintKind {
+			s.Minimum = float(0)
+		}
+	case sources.FloatKind:
+		s.Type = "number"
+		if t.Bits == 32 {
+			s.Format = "float"
+		} else {
+			s.Format = "double"
+		}
+	case sources.TimeKind:
+		s.Type = "string"
+		s.Format = "date-time"
+	case sources.DateKind:
+		s.Type = "string"
+		s.Format = "date"
+	case sources.DurationKind:
+		s.Type = "integer"
+		s.Format = "int64"
+		if s.Description == "" {
+			s.Description = "nanoseconds"
+		}
+	case sources.BytesKind:
+		s.Type = "string"
+		s.Format = "byte"
+	case sources.ArrayKind:
+		s.Type = "array"
+		s.Items = builder.Schema(t.Elem)
+	case sources.MapKind:
+		s.Type = "object"
+		s.AdditionalProperties = builder.Schema(t.Elem)
+	case sources.StructKind:
+		s.Type = "object"
+		s.Properties = make(Properties, 0, len(t.Fields))
+		for _, field := range t.Fields {
+			s.Properties = append(s.Properties, Property{Name: field.Key, Schema: builder.field(field)})
+			if field.Required() {
+				s.Required = append(s.Required, field.Key)
+			}
+		}
+	}
+	return
+}
+
+func (builder *Builder) field(field *sources.Field) (s *Schema) {
+	// copy it, keywords of field are siblings of $ref which are allowed since 2020-12
+	copied := *builder.Schema(field.Type)
+	s = &copied
+	if field.Title != "" {
+		s.Title = field.Title
+	}
+	if field.Description != "" {
+		s.Description = field.Description
+	}
+	s.Deprecated = field.Deprecated
+	applyRules(s, field.Type, sources.ValidateRules(field.Validate))
+	s.ValidateMessage = field.ValidateMessage
+	if len(field.ValidateI18n) > 0 {
+		s.ValidateI18n = make(map[string]string)
+		for _, item := range field.ValidateI18n {
+			s.ValidateI18n[item.Lang] = item.Text
+		}
+	}
+	return
+}
+
+var formats = map[string]string{
+	"email":    "email",
+	"url":      "uri",
+	"uri":      "uri",
+	"uuid":     "uuid",
+	"uuid4":    "uuid",
+	"ipv4":     "ipv4",
+	"ipv6":     "ipv6",
+	"hostname": "hostname",
+	"datetime": "date-time",
+}
+
+var patterns = map[string]string{
+	"alpha":     "^[a-zA-Z]+$",
+	"alphanum":  "^[a-zA-Z0-9]+$",
+	"numeric":   "^[-+]?[0-9]+(?:\\.[0-9]+)?$",
+	"number":    "^[0-9]+$",
+	"lowercase": "^[^A-Z]*$",
+	"uppercase": "^[^a-z]*$",
+	"ascii":     "^[\\x00-\\x7F]*$",
+}
+
+// applyRules translates rules of validate tag into keywords of schema, unknown rules are ignored.
+func applyRules(s *Schema, t *sources.Type, rules []sources.ValidateRule) {
+	isString := t.Kind == sources.StringKind
+	isArray := t.Kind == sources.ArrayKind || t.Kind == sources.MapKind
+	isNumber := t.Kind == sources.IntKind || t.Kind == sources.UintKind || t.Kind == sources.FloatKind
+	for i, rule := range rules {
+		switch rule.Name {
+		case "dive":
+			// rules after dive are rules of elements
+			if t.Kind == sources.ArrayKind && s.Items != nil && s.Items.Ref == "" {
+				items := *s.Items
+				applyRules(&items, t.Elem, rules[i+1:])
+				s.Items = &items
+			}
+			return
+		case "min", "max", "len", "gt", "gte", "lt", "lte":
+			n, ok := number(rule.Param)
+			if !ok {
+				continue
+			}
+			switch {
+			case isString || isArray:
+				size := int(n)
+				switch rule.Name {
+				case "gt":
+					size++
+				case "lt":
+					size--
+				}
+				minimum, maximum := &s.MinLength, &s.MaxLength
+				if isArray {
+					minimum, maximum = &s.MinItems, &s.MaxItems
+				}
+				switch rule.Name {
+				case "min", "gt", "gte":
+					*minimum = &size
+				case "max", "lt", "lte":
+					*maximum = &size
+				case "len":
+					*minimum = &size
+					*maximum = &size
+				}
+			case isNumber:
+				switch rule.Name {
+				case "min", "gte":
+					s.Minimum = float(n)
+				case "max", "lte":
+					s.Maximum = float(n)
+				case "gt":
+					s.ExclusiveMinimum = float(n)
+				case "lt":
+					s.ExclusiveMaximum = float(n)
+				case "len":
+					s.Minimum = float(n)
+					s.Maximum = float(n)
+				}
+			}
+		case "oneof":
+			s.Enum = make([]interface{}, 0, 1)
+			for _, item := range oneofParams(rule.Param) {
+				if n, ok := number(item); ok && isNumber {
+					s.Enum = append(s.Enum, n)
+					continue
+				}
+				s.Enum = append(s.Enum, item)
+			}
+		case "unique":
+			if isArray {
+				s.UniqueItems = true
+			}
+		case "startswith":
+			s.Pattern = "^" + quoteMeta(rule.Param)
+		case "endswith":
+			s.Pattern = quoteMeta(rule.Param) + "$"
+		case "contains":
+			s.Pattern = quoteMeta(rule.Param)
+		case "regexp":
+			s.Pattern = rule.Param
+		default:
+			if format, has := formats[rule.Name]; has && isString {
+				s.Format = format
+			} else if pattern, has := patterns[rule.Name]; has && isString {
+				s.Pattern = pattern
+			}
+		}
+	}
+}
+
+// oneofParamsRegexp splits params of oneof as validator does, values which have spaces are quoted by single quotes.
+var oneofParamsRegexp = regexp.MustCompile(`'[^']*'|\S+`)
+
+func oneofParams(param string) (values []string) {
+	values = oneofParamsRegexp.FindAllString(param, -1)
+	for i, value := range values {
+		values[i] = strings.ReplaceAll(value, "'", "")
+	}
+	return
+}
+
+func number(s string) (n float64, ok bool) {
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	ok = err == nil
+	return
+}
+
+func float(n float64) *float64 {
+	return &n
+}
+
+func quoteMeta(s string) string {
+	buf := strings.Builder{}
+	for _, c := range s {
+		if strings.ContainsRune(`\.+*?()|[]{}^$`, c) {
+			buf.WriteByte('\\')
+		}
+		buf.WriteRune(c)
+	}
+	return buf.String()
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"encoding/json"
+	"github.com/aacfactory/fnc/sources"
+	"testing"
+)
+
+func TestApplyRules(t *testing.T) {
+	stringType := &sources.Type{Kind: sources.StringKind}
+	intType := &sources.Type{Kind: sources.IntKind}
+	arrayType := &sources.Type{Kind: sources.ArrayKind, Elem: stringType}
+	cases := []struct {
+		tag    string
+		t      *sources.Type
+		schema string
+	}{
+		{tag: "required,min=1,max=10", t: stringType, schema: `{"type":"string","minLength":1,"maxLength":10}`},
+		{tag: "len=3", t: stringType, schema: `{"type":"string","minLength":3,"maxLength":3}`},
+		{tag: "gt=1,lt=10", t: stringType, schema: `{"type":"string","minLength":2,"maxLength":9}`},
+		{tag: "min=1,max=100", t: intType, schema: `{"type":"integer","minimum":1,"maximum":100}`},
+		{tag: "gte=0,lte=5.5", t: intType, schema: `{"type":"integer","minimum":0,"maximum":5.5}`},
+		{tag: "gt=0,lt=100", t: intType, schema: `{"type":"integer","exclusiveMinimum":0,"exclusiveMaximum":100}`},
+		{tag: "min=10abc,max=1e2", t: intType, schema: `{"type":"integer","maximum":100}`},
+		{tag: "oneof=red green blue", t: stringType, schema: `{"type":"string","enum":["red","green","blue"]}`},
+		{tag: "oneof='red green' blue", t: stringType, schema: `{"type":"string","enum":["red green","blue"]}`},
+		{tag: "oneof=1 2 3", t: intType, schema: `{"type":"integer","enum":[1,2,3]}`},
+		{tag: "email", t: stringType, schema: `{"type":"string","format":"email"}`},
+		{tag: "url", t: stringType, schema: `{"type":"string","format":"uri"}`},
+		{tag: "alphanum", t: stringType, schema: `{"type":"string","pattern":"^[a-zA-Z0-9]+$"}`},
+		{tag: "startswith=a.b", t: stringType, schema: `{"type":"string","pattern":"^a\\.b"}`},
+		{tag: "endswith=$", t: stringType, schema: `{"type":"string","pattern":"\\$$"}`},
+		{tag: "regexp=^[a-z]+$", t: stringType, schema: `{"type":"string","pattern":"^[a-z]+$"}`},
+		{tag: "email", t: intType, schema: `{"type":"integer"}`},
+		{tag: "min=1,max=3,unique", t: arrayType, schema: `{"type":"array","items":{"type":"string"},"minItems":1,"maxItems":3,"uniqueItems":true}`},
+		{tag: "min=1,dive,email,max=5", t: arrayType, schema: `{"type":"array","items":{"type":"string","format":"email","maxLength":5},"minItems":1}`},
+	}
+	for _, c := range cases {
+		s := &Schema{}
+		switch c.t.Kind {
+		case sources.StringKind:
+			s.Type = "string"
+		case sources.IntKind:
+			s.Type = "integer"
+		case sources.ArrayKind:
+			s.Type = "array"
+			s.Items = &Schema{Type: "string"}
+		}
+		applyRules(s, c.t, sources.ValidateRules(c.tag))
+		p, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(p) != c.schema {
+			t.Errorf("%s: got %s, want %s", c.tag, p, c.schema)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/aacfactory/fnc/schema"
+	"github.com/aacfactory/fnc/sources"
+	"github.com/urfave/cli/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var Command = &cli.Command{
+	Name:        "sdk",
+	Aliases:     nil,
+	Usage:       "fnc sdk {ts|go} --out {dir} {project path}",
+	Description: "generate client sdk of services and fns",
+	ArgsUsage:   "",
+	Category:    "",
+	Subcommands: []*cli.Command{
+		tsCommand,
+		goCommand,
+	},
+}
+
+// load returns the project in args, internal services and fns are removed, they can not be called by clients.
+func load(ctx *cli.Context) (project *sources.Project, err error) {
+	projectDir := strings.TrimSpace(ctx.Args().First())
+	if projectDir == "" {
+		projectDir = "."
+	}
+	project, err = sources.LoadValid(projectDir)
+	if err != nil {
+		return
+	}
+	services := make([]*sources.Service, 0, len(project.Services))
+	for _, service := range project.Services {
+		if service.Internal {
+			continue
+		}
+		fns := make([]*sources.Fn, 0, len(service.Fns))
+		for _, fn := range service.Fns {
+			if fn.Internal {
+				continue
+			}
+			fns = append(fns, fn)
+		}
+		service.Fns = fns
+		services = append(services, service)
+	}
+	project.Services = services
+	return
+}
+
+// namedTypes returns named types which are used by fns, they are sorted by key of builder.
+func namedTypes(builder *schema.Builder, services []*sources.Service) (types []*sources.Type) {
+	roots := make([]*sources.Type, 0, 1)
+	for _, service := range services {
+		for _, fn := range service.Fns {
+			roots = append(roots, fn.Argument, fn.Result)
+		}
+	}
+	types = builder.Refs(roots...)
+	return
+}
+
+// serviceNames returns pascal names of services, they are keyed by service names.
+// names which are reserved or are used by other services are suffixed by Service.
+func serviceNames(services []*sources.Service, reserved ...string) (names map[string]string) {
+	names = make(map[string]string)
+	used := make(map[string]bool)
+	for _, name := range reserved {
+		used[name] = true
+	}
+	for _, service := range services {
+		name := pascal(service.Name)
+		for i := 1; used[name]; i++ {
+			name = pascal(service.Name) + "Service"
+			if i > 1 {
+				name = fmt.Sprintf("%s%d", name, i)
+			}
+		}
+		used[name] = true
+		names[service.Name] = name
+	}
+	return
+}
+
+// words splits name into words by non letter or digit runes and upper case runes.
+func words(name string) (items []string) {
+	items = make([]string, 0, 1)
+	word := make([]rune, 0, len(name))
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				items = append(items, string(word))
+				word = word[:0]
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			items = append(items, string(word))
+			word = word[:0]
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		items = append(items, string(word))
+	}
+	return
+}
+
+// pascal returns the pascal case of name, e.g. get_user is GetUser.
+func pascal(name string) string {
+	buf := strings.Builder{}
+	for _, word := range words(name) {
+		buf.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return buf.String()
+}
+
+// camel returns the camel case of name, e.g. get_user is getUser.
+func camel(name string) string {
+	s := pascal(name)
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// writeFiles writes files into dir, files which are generated by last run but not in this run are removed.
+func writeFiles(dir string, header string, files map[string][]byte) (err error) {
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		err = fmt.Errorf("make dir %s failed, %v", dir, err)
+		return
+	}
+	entries, readErr := os.ReadDir(dir)
+	if readErr != nil {
+		err = fmt.Errorf("read dir %s failed, %v", dir, readErr)
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, has := files[entry.Name()]; has {
+			continue
+		}
+		filename := filepath.Join(dir, entry.Name())
+		p, _ := os.ReadFile(filename)
+		if !bytes.HasPrefix(p, []byte(header)) {
+			continue
+		}
+		if err = os.Remove(filename); err != nil {
+			err = fmt.Errorf("remove %s failed, %v", filename, err)
+			return
+		}
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		filename := filepath.Join(dir, name)
+		if prev, readErr := os.ReadFile(filename); readErr == nil && bytes.Equal(prev, files[name]) {
+			continue
+		}
+		if err = os.WriteFile(filename, files[name], 0644); err != nil {
+			err = fmt.Errorf("write %s failed, %v", filename, err)
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,352 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/fnc/schema"
+	"github.com/aacfactory/fnc/sources"
+	"github.com/urfave/cli/v2"
+	"go/format"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const goHeader = "// Code generated by fnc sdk go. DO NOT EDIT.\n"
+
+var goCommand = &cli.Command{
+	Name: "go",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "out",
+			Aliases:  []string{"o"},
+			Value:    "client",
+			Usage:    "output dir",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "package",
+			Usage:    "package name, default is the base name of output dir",
+			Required: false,
+		},
+	},
+	Usage:       "fnc sdk go --out ./client {project path}",
+	Description: "generate a go package which calls services over http",
+	Action: func(ctx *cli.Context) (err error) {
+		project, loadErr := load(ctx)
+		if loadErr != nil {
+			err = errors.Warning("fnc: generate go sdk failed").WithCause(loadErr)
+			return
+		}
+		out := ctx.String("out")
+		pkg := strings.TrimSpace(ctx.String("package"))
+		if pkg == "" {
+			abs, absErr := filepath.Abs(out)
+			if absErr != nil {
+				err = errors.Warning("fnc: generate go sdk failed").WithCause(absErr)
+				return
+			}
+			pkg = strings.ToLower(strings.Join(words(filepath.Base(abs)), ""))
+		}
+		if !token.IsIdentifier(pkg) || token.IsKeyword(pkg) {
+			err = errors.Warning("fnc: generate go sdk failed").WithCause(errors.Warning("package name is invalid")).WithMeta("package", pkg)
+			return
+		}
+		files, filesErr := newGolang(project, pkg).files()
+		if filesErr != nil {
+			err = errors.Warning("fnc: generate go sdk failed").WithCause(filesErr)
+			return
+		}
+		if err = writeFiles(out, goHeader, files); err != nil {
+			err = errors.Warning("fnc: generate go sdk failed").WithCause(err)
+			return
+		}
+		fmt.Println(fmt.Sprintf("fnc: go sdk has been written into %s", out))
+		return
+	},
+}
+
+// goReserved are exported identifiers of client.go, names of types must not be them.
+var goReserved = []string{"Option", "WithHTTPClient", "WithHeader", "WithAuthorization", "NewClient", "Client"}
+
+type golang struct {
+	project *sources.Project
+	pkg     string
+	builder *schema.Builder
+	types   []*sources.Type
+	// clients are go names of services, they are keyed by service names
+	clients map[string]string
+}
+
+func newGolang(project *sources.Project, pkg string) *golang {
+	// go names of types are exported, so keys are case-insensitive, e.g. user and User are both User
+	builder := schema.NewBuilder("")
+	builder.FoldKeys(strings.ToLower)
+	builder.Reserve(goReserved...)
+	// services are accessors of Client, so they must not be Request
+	clients := serviceNames(project.Services, "Request")
+	for _, name := range clients {
+		builder.Reserve(name + "Client")
+	}
+	return &golang{
+		project: project,
+		pkg:     pkg,
+		builder: builder,
+		types:   namedTypes(builder, project.Services),
+		clients: clients,
+	}
+}
+
+// files returns client.go, types.go and a file per service, they are formatted by gofmt.
+func (g *golang) files() (files map[string][]byte, err error) {
+	files = make(map[string][]byte)
+	srcs := map[string][]byte{
+		"client.go": []byte(goHeader + "\npackage " + g.pkg + "\n" + goClient),
+		"types.go":  g.typesFile(),
+	}
+	for _, service := range g.project.Services {
+		name := service.Name + ".go"
+		if name == "client.go" || name == "types.go" || strings.HasSuffix(service.Name, "_test") {
+			name = service.Name + "_service.go"
+		}
+		srcs[name] = g.serviceFile(service)
+	}
+	for name, p := range srcs {
+		formatted, formatErr := format.Source(p)
+		if formatErr != nil {
+			err = fmt.Errorf("format %s failed, %v", name, formatErr)
+			return
+		}
+		files[name] = formatted
+	}
+	return
+}
+
+func (g *golang) typesFile() []byte {
+	body := bytes.NewBuffer(nil)
+	imports := make(map[string]bool)
+	for _, t := range g.types {
+		body.WriteString("\n")
+		name := g.name(t)
+		goDoc(body, name, t.Title, t.Description)
+		// t is referenced, so its declaration is built from elem or fields, typeOf returns the name of t
+		switch t.Kind {
+		case sources.ArrayKind:
+			_, _ = fmt.Fprintf(body, "type %s []%s\n", name, g.typeOf(t.Elem, imports))
+			break
+		case sources.MapKind:
+			_, _ = fmt.Fprintf(body, "type %s map[string]%s\n", name, g.typeOf(t.Elem, imports))
+			break
+		default:
+			_, _ = fmt.Fprintf(body, "type %s %s\n", name, g.structOf(t, imports))
+			break
+		}
+	}
+	buf := bytes.NewBufferString(goHeader)
+	_, _ = fmt.Fprintf(buf, "\npackage %s\n", g.pkg)
+	if imports["time"] {
+		buf.WriteString("\nimport \"time\"\n")
+	}
+	buf.Write(body.Bytes())
+	return buf.Bytes()
+}
+
+func (g *golang) serviceFile(service *sources.Service) []byte {
+	body := bytes.NewBuffer(nil)
+	imports := make(map[string]bool)
+	accessor := g.clients[service.Name]
+	client := accessor + "Client"
+	lines := []string{service.Title, service.Description, fmt.Sprintf("It calls fns of %s service over http.", service.Name)}
+	goDoc(body, client, lines...)
+	_, _ = fmt.Fprintf(body, "type %s struct {\n\tclient *Client\n}\n\n", client)
+	_, _ = fmt.Fprintf(body, "// %s returns the client of %s service.\n", accessor, service.Name)
+	_, _ = fmt.Fprintf(body, "func (client *Client) %s() *%s {\n\treturn &%s{client: client}\n}\n", accessor, client, client)
+	for _, fn := range service.Fns {
+		body.WriteString("\n")
+		lines = []string{fn.Title, fn.Description}
+		if fn.Timeout > 0 {
+			lines = append(lines, "timeout: "+fn.Timeout.String())
+		}
+		if len(fn.Errors) > 0 {
+			lines = append(lines, "errors:")
+			for _, e := range fn.Errors {
+				texts := make([]string, 0, len(e.Descriptions))
+				for _, d := range e.Descriptions {
+					texts = append(texts, fmt.Sprintf("%s: %s", d.Lang, d.Text))
+				}
+				lines = append(lines, strings.TrimSpace(fmt.Sprintf("- %s %s", e.Name, strings.Join(texts, "; "))))
+			}
+		}
+		if fn.Deprecated {
+			lines = append(lines, "Deprecated: see description.")
+		}
+		method := pascal(fn.Name)
+		goDoc(body, method, lines...)
+		params, argument := "ctx context.Context", "nil"
+		if fn.Argument != nil {
+			params, argument = params+", argument "+g.typeOf(fn.Argument, imports), "argument"
+		}
+		results, result := "err errors.CodeError", "nil"
+		if fn.Result != nil {
+			results, result = "result "+g.typeOf(fn.Result, imports)+", "+results, "&result"
+		}
+		timeout := "0"
+		if fn.Timeout > 0 {
+			timeout = goDuration(fn.Timeout)
+			imports["time"] = true
+		}
+		_, _ = fmt.Fprintf(body, "func (service *%s) %s(%s) (%s) {\n", client, method, params, results)
+		_, _ = fmt.Fprintf(body, "\terr = service.client.Request(ctx, %q, %q, %s, %s, %s, %t)\n\treturn\n}\n", service.Name, fn.Name, argument, result, timeout, fn.Authorization)
+	}
+	buf := bytes.NewBufferString(goHeader)
+	_, _ = fmt.Fprintf(buf, "\npackage %s\n\n", g.pkg)
+	// a service without fns only has its client
+	if len(service.Fns) > 0 {
+		buf.WriteString("import (\n\t\"context\"\n\t\"github.com/aacfactory/errors\"\n")
+		if imports["time"] {
+			buf.WriteString("\t\"time\"\n")
+		}
+		buf.WriteString(")\n\n")
+	}
+	buf.Write(body.Bytes())
+	return buf.Bytes()
+}
+
+// name returns the exported go name of named type.
+func (g *golang) name(t *sources.Type) string {
+	key := g.builder.Key(t)
+	return strings.ToUpper(key[:1]) + key[1:]
+}
+
+// typeOf returns the go type of t, named structs, arrays and maps are copied, others are their underlying types.
+func (g *golang) typeOf(t *sources.Type, imports map[string]bool) string {
+	if schema.Referenced(t) {
+		return g.name(t)
+	}
+	switch t.Kind {
+	case sources.StringKind, sources.DateKind:
+		return "string"
+	case sources.BoolKind:
+		return "bool"
+	case sources.IntKind, sources.UintKind:
+		if t.Bits > 0 {
+			return fmt.Sprintf("%s%d", t.Kind, t.Bits)
+		}
+		return string(t.Kind)
+	case sources.FloatKind:
+		if t.Bits == 32 {
+			return "float32"
+		}
+		return "float64"
+	case sources.TimeKind:
+		imports["time"] = true
+		return "time.Time"
+	case sources.DurationKind:
+		imports["time"] = true
+		return "time.Duration"
+	case sources.BytesKind:
+		return "[]byte"
+	case sources.ArrayKind:
+		return "[]" + g.typeOf(t.Elem, imports)
+	case sources.MapKind:
+		return "map[string]" + g.typeOf(t.Elem, imports)
+	case sources.StructKind:
+		return g.structOf(t, imports)
+	default:
+		return "interface{}"
+	}
+}
+
+// structOf returns the struct type of t, fields of struct type are pointers, so recursive types are valid.
+func (g *golang) structOf(t *sources.Type, imports map[string]bool) string {
+	buf := bytes.NewBufferString("struct {\n")
+	for _, field := range t.Fields {
+		lines := []string{field.Title, field.Description}
+		if field.Deprecated {
+			lines = append(lines, "Deprecated: see description.")
+		}
+		name := field.Name
+		if !token.IsExported(name) {
+			name = pascal(field.Key)
+		}
+		goDoc(buf, name, lines...)
+		typ := g.typeOf(field.Type, imports)
+		if field.Type.Kind == sources.StructKind {
+			typ = "*" + typ
+		}
+		tag := field.Key
+		if field.Omitempty {
+			tag = tag + ",omitempty"
+		}
+		tags := fmt.Sprintf("json:%s", strconv.Quote(tag))
+		if field.Validate != "" {
+			tags = tags + fmt.Sprintf(" validate:%s", strconv.Quote(field.Validate))
+		}
+		if field.ValidateMessage != "" {
+			tags = tags + fmt.Sprintf(" validate-message:%s", strconv.Quote(field.ValidateMessage))
+		}
+		_, _ = fmt.Fprintf(buf, "%s %s `%s`\n", name, typ, tags)
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+// goDuration returns the go expression of d, e.g. 2 * time.Second.
+func goDuration(d time.Duration) string {
+	units := []struct {
+		d    time.Duration
+		name string
+	}{
+		{time.Hour, "time.Hour"},
+		{time.Minute, "time.Minute"},
+		{time.Second, "time.Second"},
+		{time.Millisecond, "time.Millisecond"},
+	}
+	for _, unit := range units {
+		if d%unit.d == 0 {
+			return fmt.Sprintf("%d * %s", d/unit.d, unit.name)
+		}
+	}
+	return fmt.Sprintf("time.Duration(%d)", d)
+}
+
+// goDoc writes the doc comment of name, the first line starts with name.
+func goDoc(buf *bytes.Buffer, name string, lines ...string) {
+	items := make([]string, 0, len(lines))
+	for _, line := range lines {
+		for _, item := range strings.Split(strings.TrimSpace(line), "\n") {
+			if item = strings.TrimRight(item, " \t"); strings.TrimSpace(item) != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	if len(items) == 0 {
+		return
+	}
+	if items[0] == name {
+		items = items[1:]
+	}
+	_, _ = fmt.Fprintf(buf, "// %s\n", name)
+	for _, item := range items {
+		_, _ = fmt.Fprintf(buf, "// %s\n", item)
+	}
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+// goClient is the content of client.go after package clause, it sends fn requests by net/http.
+const goClient = `
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Option sets options of client.
+type Option func(client *Client)
+
+// WithHTTPClient sets the http client, default is http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(client *Client) {
+		client.httpClient = httpClient
+	}
+}
+
+// WithHeader adds a header into every request.
+func WithHeader(name string, value string) Option {
+	return func(client *Client) {
+		client.header.Add(name, value)
+	}
+}
+
+// WithAuthorization sets the token provider of authorization header, it is called when fn requires authorization.
+func WithAuthorization(token func(ctx context.Context) (string, error)) Option {
+	return func(client *Client) {
+		client.authorization = token
+	}
+}
+
+// NewClient returns a client of fns server, baseURL is the url of server, e.g. https://api.example.com.
+func NewClient(baseURL string, options ...Option) *Client {
+	client := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		header:     http.Header{},
+	}
+	for _, option := range options {
+		option(client)
+	}
+	return client
+}
+
+// Client sends fn requests to fns server over http.
+type Client struct {
+	baseURL       string
+	httpClient    *http.Client
+	header        http.Header
+	authorization func(ctx context.Context) (string, error)
+}
+
+// Request calls fn of service, argument is encoded in json and result is decoded from json when it is not nil.
+// The timeout is used when ctx has no earlier deadline, authorization header is set when authorization is true.
+// Errors of fns are decoded into errors.CodeError.
+func (client *Client) Request(ctx context.Context, service string, fn string, argument interface{}, result interface{}, timeout time.Duration, authorization bool) (err errors.CodeError) {
+	if timeout > 0 {
+		if deadline, has := ctx.Deadline(); !has || time.Until(deadline) > timeout {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+	}
+	if argument == nil {
+		argument = struct{}{}
+	}
+	body, encodeErr := json.Marshal(argument)
+	if encodeErr != nil {
+		err = errors.BadRequest("fns: encode argument failed").WithCause(encodeErr).WithMeta("service", service).WithMeta("fn", fn)
+		return
+	}
+	request, requestErr := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/%s/%s", client.baseURL, service, fn), bytes.NewReader(body))
+	if requestErr != nil {
+		err = errors.Warning("fns: create request failed").WithCause(requestErr).WithMeta("service", service).WithMeta("fn", fn)
+		return
+	}
+	for name, values := range client.header {
+		for _, value := range values {
+			request.Header.Add(name, value)
+		}
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if authorization && client.authorization != nil {
+		token, tokenErr := client.authorization(ctx)
+		if tokenErr != nil {
+			err = errors.Unauthorized("fns: get authorization token failed").WithCause(tokenErr).WithMeta("service", service).WithMeta("fn", fn)
+			return
+		}
+		if token != "" {
+			request.Header.Set("Authorization", token)
+		}
+	}
+	response, doErr := client.httpClient.Do(request)
+	if doErr != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = errors.Timeout("fns: request timeout").WithCause(doErr).WithMeta("service", service).WithMeta("fn", fn)
+			return
+		}
+		err = errors.Unavailable("fns: send request failed").WithCause(doErr).WithMeta("service", service).WithMeta("fn", fn)
+		return
+	}
+	defer response.Body.Close()
+	p, readErr := io.ReadAll(response.Body)
+	if readErr != nil {
+		err = errors.Warning("fns: read response failed").WithCause(readErr).WithMeta("service", service).WithMeta("fn", fn)
+		return
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		if len(p) > 0 && json.Valid(p) {
+			if decoded := errors.Decode(p); decoded.Code() > 0 {
+				err = decoded
+				return
+			}
+		}
+		err = errors.New(response.StatusCode, response.Status, strings.TrimSpace(string(p))).WithMeta("service", service).WithMeta("fn", fn)
+		return
+	}
+	if result == nil || len(p) == 0 {
+		return
+	}
+	if decodeErr := json.Unmarshal(p, result); decodeErr != nil {
+		err = errors.Warning("fns: decode result failed").WithCause(decodeErr).WithMeta("service", service).WithMeta("fn", fn)
+		return
+	}
+	return
+}
+`
@@ -0,0 +1,159 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk_test
+
+import (
+	"context"
+	"github.com/aacfactory/fnc/create/files"
+	"github.com/aacfactory/fnc/sdk"
+	"github.com/urfave/cli/v2"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const reservedFns = `package users
+
+import (
+	"context"
+	"example.com/project/bar"
+	"example.com/project/foo"
+	"github.com/aacfactory/errors"
+)
+
+type Client struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+type Option struct {
+	Value string ` + "`json:\"value\"`" + `
+}
+
+type NewClient struct {
+	Value string ` + "`json:\"value\"`" + `
+}
+
+type WithHeader struct {
+	Value string ` + "`json:\"value\"`" + `
+}
+
+type UsersClient struct {
+	Id string ` + "`json:\"id\"`" + `
+}
+
+type GetArgument struct {
+	Client      Client      ` + "`json:\"client\"`" + `
+	Option      Option      ` + "`json:\"option\"`" + `
+	NewClient   NewClient   ` + "`json:\"newClient\"`" + `
+	WithHeader  WithHeader  ` + "`json:\"withHeader\"`" + `
+	UsersClient UsersClient ` + "`json:\"usersClient\"`" + `
+	Foo         foo.User    ` + "`json:\"foo\"`" + `
+	Bar         bar.User    ` + "`json:\"bar\"`" + `
+}
+
+// @fn get
+func get(ctx context.Context, argument GetArgument) (result *Client, err errors.CodeError) {
+	return
+}
+`
+
+func writeGolangProject(t *testing.T, files map[string]string) string {
+	projectDir := t.TempDir()
+	for name, content := range files {
+		filename := filepath.Join(projectDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return projectDir
+}
+
+// vetGolang generates the go sdk of project and vets it, the output dir is returned.
+func vetGolang(t *testing.T, projectDir string) string {
+	out := t.TempDir()
+	app := &cli.App{Commands: []*cli.Command{sdk.Command}}
+	if err := app.Run([]string{"fnc", "sdk", "go", "--out", out, "--package", "client", projectDir}); err != nil {
+		t.Fatal(err)
+	}
+	mod := "module example.com/client\n\ngo 1.20\n\nrequire github.com/aacfactory/errors v1.13.4\n"
+	if err := os.WriteFile(filepath.Join(out, "go.mod"), []byte(mod), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum, sumErr := os.ReadFile(filepath.Join("..", "go.sum"))
+	if sumErr != nil {
+		t.Fatal(sumErr)
+	}
+	if err := os.WriteFile(filepath.Join(out, "go.sum"), sum, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("go", "vet", "./...")
+	cmd.Dir = out
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	if p, err := cmd.CombinedOutput(); err != nil {
+		t.Fatal(err, string(p))
+	}
+	return out
+}
+
+func TestGolang(t *testing.T) {
+	projectDir := writeGolangProject(t, map[string]string{"go.mod": "module example.com/project\n\ngo 1.20\n"})
+	modules, modulesErr := files.NewModulesFile("example.com/project", projectDir)
+	if modulesErr != nil {
+		t.Fatal(modulesErr)
+	}
+	if err := modules.Write(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	vetGolang(t, projectDir)
+}
+
+func TestGolangReservedNames(t *testing.T) {
+	projectDir := writeGolangProject(t, map[string]string{
+		"go.mod":                 "module example.com/project\n\ngo 1.20\n",
+		"foo/user.go":            "package foo\n\ntype User struct {\n\tRaw user `json:\"raw\"`\n}\n\ntype user struct {\n\tId string `json:\"id\"`\n}\n",
+		"bar/user.go":            "package bar\n\ntype User struct {\n\tName string `json:\"name\"`\n}\n",
+		"modules/users/doc.go":   "// Package users\n// @service users\npackage users\n",
+		"modules/users/fns.go":   reservedFns,
+		"modules/request/doc.go": "// Package request\n// @service request\npackage request\n",
+		"modules/request/fns.go": "package request\n\nimport (\n\t\"context\"\n\t\"github.com/aacfactory/errors\"\n)\n\n// @fn request\nfunc request(ctx context.Context) (err errors.CodeError) {\n\treturn\n}\n",
+	})
+	out := vetGolang(t, projectDir)
+	request, readErr := os.ReadFile(filepath.Join(out, "request.go"))
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	if want := "func (client *Client) RequestService() *RequestServiceClient {"; !strings.Contains(string(request), want) {
+		t.Errorf("%s is not found in request.go:\n%s", want, request)
+	}
+	types, typesErr := os.ReadFile(filepath.Join(out, "types.go"))
+	if typesErr != nil {
+		t.Fatal(typesErr)
+	}
+	for _, name := range []string{"Client", "Option", "NewClient", "WithHeader", "UsersClient"} {
+		if strings.Contains(string(types), "\ntype "+name+" ") {
+			t.Errorf("type %s is declared in types.go:\n%s", name, types)
+		}
+	}
+	if n := strings.Count(string(types), "User struct {") + strings.Count(string(types), "user struct {"); n != 3 {
+		t.Errorf("got %d user types, want 3:\n%s", n, types)
+	}
+}
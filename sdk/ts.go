@@ -0,0 +1,317 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/fnc/schema"
+	"github.com/aacfactory/fnc/sources"
+	"github.com/urfave/cli/v2"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const tsHeader = "// This file was automatically generated by fnc sdk ts, DON'T EDIT IT.\n"
+
+var tsCommand = &cli.Command{
+	Name: "ts",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "out",
+			Aliases:  []string{"o"},
+			Value:    "sdk",
+			Usage:    "output dir",
+			Required: false,
+		},
+	},
+	Usage:       "fnc sdk ts --out ./web/sdk {project path}",
+	Description: "generate typescript interfaces and clients of services",
+	Action: func(ctx *cli.Context) (err error) {
+		project, loadErr := load(ctx)
+		if loadErr != nil {
+			err = errors.Warning("fnc: generate typescript sdk failed").WithCause(loadErr)
+			return
+		}
+		out := ctx.String("out")
+		if err = writeFiles(out, tsHeader, newTs(project).files()); err != nil {
+			err = errors.Warning("fnc: generate typescript sdk failed").WithCause(err)
+			return
+		}
+		fmt.Println(fmt.Sprintf("fnc: typescript sdk has been written into %s", out))
+		return
+	},
+}
+
+// tsReserved are names which are exported by client.ts and index.ts, names of types must not be them.
+var tsReserved = []string{"CodeError", "FnsError", "ClientOptions", "RequestOptions", "Client", "Fns"}
+
+type ts struct {
+	project *sources.Project
+	builder *schema.Builder
+	types   []*sources.Type
+	// clients are pascal names of services, they are keyed by service names
+	clients map[string]string
+}
+
+func newTs(project *sources.Project) *ts {
+	builder := schema.NewBuilder("")
+	builder.Reserve(tsReserved...)
+	// camel names of services are fields of Fns, so they must not be client
+	clients := serviceNames(project.Services, "Client")
+	for _, name := range clients {
+		builder.Reserve(name + "Client")
+	}
+	return &ts{
+		project: project,
+		builder: builder,
+		types:   namedTypes(builder, project.Services),
+		clients: clients,
+	}
+}
+
+// files returns types.ts, client.ts, index.ts and a file per service.
+func (g *ts) files() (files map[string][]byte) {
+	files = make(map[string][]byte)
+	files["types.ts"] = g.typesFile()
+	files["client.ts"] = []byte(tsHeader + tsClient)
+	for _, service := range g.project.Services {
+		files[tsModule(service)+".ts"] = g.serviceFile(service)
+	}
+	files["index.ts"] = g.indexFile()
+	return
+}
+
+func (g *ts) typesFile() []byte {
+	buf := bytes.NewBufferString(tsHeader)
+	for _, t := range g.types {
+		buf.WriteString("\n")
+		tsDoc(buf, "", t.Title, t.Description)
+		key := g.builder.Key(t)
+		if t.Kind != sources.StructKind {
+			_, _ = fmt.Fprintf(buf, "export type %s = %s;\n", key, tsContainer(t, g.typeOf(t.Elem)))
+			continue
+		}
+		_, _ = fmt.Fprintf(buf, "export interface %s {\n", key)
+		for _, field := range t.Fields {
+			lines := []string{field.Title, field.Description}
+			if field.Validate != "" {
+				lines = append(lines, "validate: "+field.Validate)
+			}
+			if field.ValidateMessage != "" {
+				lines = append(lines, "validate message: "+field.ValidateMessage)
+			}
+			for _, item := range field.ValidateI18n {
+				lines = append(lines, fmt.Sprintf("validate message(%s): %s", item.Lang, item.Text))
+			}
+			if field.Deprecated {
+				lines = append(lines, "@deprecated")
+			}
+			tsDoc(buf, "  ", lines...)
+			optional := ""
+			if field.Omitempty {
+				optional = "?"
+			}
+			_, _ = fmt.Fprintf(buf, "  %s%s: %s;\n", tsKey(field.Key), optional, g.typeOf(field.Type))
+		}
+		buf.WriteString("}\n")
+	}
+	return buf.Bytes()
+}
+
+func (g *ts) serviceFile(service *sources.Service) []byte {
+	buf := bytes.NewBufferString(tsHeader)
+	imports := make(map[string]bool)
+	for _, fn := range service.Fns {
+		for _, t := range []*sources.Type{fn.Argument, fn.Result} {
+			if t != nil && schema.Referenced(t) {
+				imports[g.builder.Key(t)] = true
+			}
+		}
+	}
+	buf.WriteString("\nimport { Client, RequestOptions } from './client';\n")
+	if len(imports) > 0 {
+		names := make([]string, 0, len(imports))
+		for name := range imports {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		_, _ = fmt.Fprintf(buf, "import { %s } from './types';\n", strings.Join(names, ", "))
+	}
+	buf.WriteString("\n")
+	tsDoc(buf, "", service.Title, service.Description)
+	_, _ = fmt.Fprintf(buf, "export class %sClient {\n", g.clients[service.Name])
+	buf.WriteString("  constructor(private readonly client: Client) {}\n")
+	for _, fn := range service.Fns {
+		buf.WriteString("\n")
+		lines := []string{fn.Title, fn.Description}
+		if fn.Timeout > 0 {
+			lines = append(lines, "timeout: "+fn.Timeout.String())
+		}
+		for _, e := range fn.Errors {
+			texts := make([]string, 0, len(e.Descriptions))
+			for _, d := range e.Descriptions {
+				texts = append(texts, fmt.Sprintf("%s: %s", d.Lang, d.Text))
+			}
+			lines = append(lines, strings.TrimSpace(fmt.Sprintf("@throws {FnsError} %s %s", e.Name, strings.Join(texts, "; "))))
+		}
+		if fn.Deprecated {
+			lines = append(lines, "@deprecated")
+		}
+		tsDoc(buf, "  ", lines...)
+		result := "void"
+		if fn.Result != nil {
+			result = g.typeOf(fn.Result)
+		}
+		params, argument := "", "undefined"
+		if fn.Argument != nil {
+			params, argument = "argument: "+g.typeOf(fn.Argument)+", ", "argument"
+		}
+		settings := make([]string, 0, 2)
+		if fn.Authorization {
+			settings = append(settings, "authorization: true")
+		}
+		if fn.Timeout > 0 {
+			settings = append(settings, fmt.Sprintf("timeout: %d", fn.Timeout.Milliseconds()))
+		}
+		settings = append(settings, "...options")
+		_, _ = fmt.Fprintf(buf, "  async %s(%soptions?: RequestOptions): Promise<%s> {\n", camel(fn.Name), params, result)
+		_, _ = fmt.Fprintf(buf, "    return this.client.request<%s>('%s', '%s', %s, { %s });\n", result, service.Name, fn.Name, argument, strings.Join(settings, ", "))
+		buf.WriteString("  }\n")
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+func (g *ts) indexFile() []byte {
+	buf := bytes.NewBufferString(tsHeader)
+	buf.WriteString("\nimport { Client, ClientOptions } from './client';\n")
+	for _, service := range g.project.Services {
+		_, _ = fmt.Fprintf(buf, "import { %sClient } from './%s';\n", g.clients[service.Name], tsModule(service))
+	}
+	buf.WriteString("\nexport * from './client';\nexport * from './types';\n")
+	for _, service := range g.project.Services {
+		_, _ = fmt.Fprintf(buf, "export * from './%s';\n", tsModule(service))
+	}
+	buf.WriteString("\n")
+	tsDoc(buf, "", "Fns is the client of all services.")
+	buf.WriteString("export class Fns {\n")
+	buf.WriteString("  readonly client: Client;\n")
+	for _, service := range g.project.Services {
+		_, _ = fmt.Fprintf(buf, "  readonly %s: %sClient;\n", g.field(service), g.clients[service.Name])
+	}
+	buf.WriteString("\n  constructor(options: ClientOptions) {\n")
+	buf.WriteString("    this.client = new Client(options);\n")
+	for _, service := range g.project.Services {
+		_, _ = fmt.Fprintf(buf, "    this.%s = new %sClient(this.client);\n", g.field(service), g.clients[service.Name])
+	}
+	buf.WriteString("  }\n}\n")
+	return buf.Bytes()
+}
+
+// field returns the field name of service in Fns.
+func (g *ts) field(service *sources.Service) string {
+	name := g.clients[service.Name]
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// tsModule returns the module name of service, it is suffixed when it conflicts with common modules.
+func tsModule(service *sources.Service) string {
+	switch service.Name {
+	case "client", "types", "index":
+		return service.Name + "_service"
+	}
+	return service.Name
+}
+
+// typeOf returns the typescript type of t, named structs, arrays and maps are referenced by name.
+func (g *ts) typeOf(t *sources.Type) string {
+	if schema.Referenced(t) {
+		return g.builder.Key(t)
+	}
+	switch t.Kind {
+	case sources.StringKind, sources.TimeKind, sources.DateKind, sources.BytesKind:
+		return "string"
+	case sources.BoolKind:
+		return "boolean"
+	case sources.IntKind, sources.UintKind, sources.FloatKind, sources.DurationKind:
+		return "number"
+	case sources.ArrayKind, sources.MapKind:
+		return tsContainer(t, g.typeOf(t.Elem))
+	case sources.StructKind:
+		items := make([]string, 0, len(t.Fields))
+		for _, field := range t.Fields {
+			optional := ""
+			if field.Omitempty {
+				optional = "?"
+			}
+			items = append(items, fmt.Sprintf("%s%s: %s;", tsKey(field.Key), optional, g.typeOf(field.Type)))
+		}
+		if len(items) == 0 {
+			return "Record<string, never>"
+		}
+		return "{ " + strings.Join(items, " ") + " }"
+	default:
+		return "unknown"
+	}
+}
+
+// tsContainer returns the array or map type of elem.
+func tsContainer(t *sources.Type, elem string) string {
+	if t.Kind == sources.MapKind {
+		return "Record<string, " + elem + ">"
+	}
+	if strings.ContainsAny(elem, " |") {
+		return "(" + elem + ")[]"
+	}
+	return elem + "[]"
+}
+
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func tsKey(key string) string {
+	if tsIdentifier.MatchString(key) {
+		return key
+	}
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(key, "'", "\\'"))
+}
+
+// tsDoc writes non empty lines as a jsdoc comment.
+func tsDoc(buf *bytes.Buffer, indent string, lines ...string) {
+	items := make([]string, 0, len(lines))
+	for _, line := range lines {
+		for _, item := range strings.Split(strings.TrimSpace(line), "\n") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, strings.ReplaceAll(item, "*/", "*\\/"))
+			}
+		}
+	}
+	if len(items) == 0 {
+		return
+	}
+	if len(items) == 1 {
+		_, _ = fmt.Fprintf(buf, "%s/** %s */\n", indent, items[0])
+		return
+	}
+	_, _ = fmt.Fprintf(buf, "%s/**\n", indent)
+	for _, item := range items {
+		_, _ = fmt.Fprintf(buf, "%s * %s\n", indent, item)
+	}
+	_, _ = fmt.Fprintf(buf, "%s */\n", indent)
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+// tsClient is the content of client.ts, it sends fn requests by fetch.
+const tsClient = `
+/** CodeError is the error of fns in json. */
+export interface CodeError {
+  id?: string;
+  code: number;
+  name: string;
+  message: string;
+  meta?: Record<string, string>;
+  stacktrace?: { fn: string; file: string; line: number };
+  cause?: CodeError;
+}
+
+/** FnsError is thrown when fn is failed, code is the code of fns error, it is also the status of response. */
+export class FnsError extends Error {
+  readonly id: string;
+  readonly code: number;
+  readonly meta: Record<string, string>;
+  readonly cause?: CodeError;
+
+  constructor(e: CodeError) {
+    super(e.message);
+    this.id = e.id ?? '';
+    this.code = e.code;
+    this.name = e.name;
+    this.meta = e.meta ?? {};
+    this.cause = e.cause;
+  }
+}
+
+export interface ClientOptions {
+  /** url of fns server, e.g. https://api.example.com */
+  baseUrl: string;
+  /** headers of every request */
+  headers?: Record<string, string>;
+  /** token of authorization header, it is sent when fn requires authorization */
+  authorization?: string | (() => string | Promise<string>);
+  /** fetch implementation, default is global fetch */
+  fetch?: typeof fetch;
+}
+
+export interface RequestOptions {
+  headers?: Record<string, string>;
+  signal?: AbortSignal;
+  /** timeout in milliseconds, default is @timeout of fn */
+  timeout?: number;
+  /** send authorization header, default is true when fn requires authorization */
+  authorization?: boolean;
+}
+
+export class Client {
+  constructor(private readonly options: ClientOptions) {}
+
+  async request<R>(service: string, fn: string, argument: unknown, options: RequestOptions = {}): Promise<R> {
+    const headers: Record<string, string> = {
+      'Content-Type': 'application/json',
+      ...this.options.headers,
+      ...options.headers,
+    };
+    if (options.authorization && this.options.authorization) {
+      const token = typeof this.options.authorization === 'function' ? await this.options.authorization() : this.options.authorization;
+      if (token) {
+        headers['Authorization'] = token;
+      }
+    }
+    const controller = new AbortController();
+    const abort = () => controller.abort();
+    options.signal?.addEventListener('abort', abort);
+    const timer = options.timeout ? setTimeout(abort, options.timeout) : undefined;
+    try {
+      const response = await (this.options.fetch ?? fetch)(this.options.baseUrl.replace(/\/+$/, '') + '/' + service + '/' + fn, {
+        method: 'POST',
+        headers,
+        body: JSON.stringify(argument ?? {}),
+        signal: controller.signal,
+      });
+      const text = await response.text();
+      const body = text ? JSON.parse(text) : undefined;
+      if (!response.ok) {
+        throw new FnsError(body && typeof body === 'object' ? body : { code: response.status, name: response.statusText, message: text });
+      }
+      return body as R;
+    } catch (e) {
+      if (e instanceof FnsError) {
+        throw e;
+      }
+      if (controller.signal.aborted && !options.signal?.aborted) {
+        throw new FnsError({ code: 408, name: '***TIMEOUT***', message: service + '/' + fn + ' is timeout' });
+      }
+      throw e;
+    } finally {
+      if (timer) {
+        clearTimeout(timer);
+      }
+      options.signal?.removeEventListener('abort', abort);
+    }
+  }
+}
+`
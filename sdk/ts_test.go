@@ -0,0 +1,156 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk_test
+
+import (
+	"bytes"
+	"flag"
+	"github.com/aacfactory/fnc/sdk"
+	"github.com/urfave/cli/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files of sdk")
+
+const tsFns = `package users
+
+import (
+	"context"
+	"github.com/aacfactory/errors"
+)
+
+type Client struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+type ClientOptions struct {
+	Value string ` + "`json:\"value\"`" + `
+}
+
+type RequestOptions struct {
+	Value string ` + "`json:\"value\"`" + `
+}
+
+type FnsError struct {
+	Value string ` + "`json:\"value\"`" + `
+}
+
+type UsersClient struct {
+	Id string ` + "`json:\"id\"`" + `
+}
+
+// GetArgument
+// @title get argument
+type GetArgument struct {
+	// @title client
+	Client         Client         ` + "`json:\"client\" validate:\"required\"`" + `
+	ClientOptions  ClientOptions  ` + "`json:\"clientOptions\"`" + `
+	RequestOptions RequestOptions ` + "`json:\"requestOptions,omitempty\"`" + `
+	FnsError       FnsError       ` + "`json:\"fnsError\"`" + `
+	UsersClient    []UsersClient  ` + "`json:\"usersClient\"`" + `
+}
+
+// get
+// @fn get
+// @title get user
+// @timeout 2s
+// @authorization
+func get(ctx context.Context, argument GetArgument) (result *Client, err errors.CodeError) {
+	return
+}
+`
+
+// tsFiles generates typescript sdk of project into a temp dir, and returns files of it.
+func tsFiles(t *testing.T, projectDir string) map[string][]byte {
+	out := t.TempDir()
+	app := &cli.App{Commands: []*cli.Command{sdk.Command}}
+	if err := app.Run([]string{"fnc", "sdk", "ts", "--out", out, projectDir}); err != nil {
+		t.Fatal(err)
+	}
+	entries, readErr := os.ReadDir(out)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	files := make(map[string][]byte)
+	for _, entry := range entries {
+		p, err := os.ReadFile(filepath.Join(out, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[entry.Name()] = p
+	}
+	return files
+}
+
+func TestTsGolden(t *testing.T) {
+	projectDir := writeGolangProject(t, map[string]string{
+		"go.mod":                "module example.com/project\n\ngo 1.20\n",
+		"modules/users/doc.go":  "// Package users\n// @service users\npackage users\n",
+		"modules/users/fns.go":  tsFns,
+		"modules/client/doc.go": "// Package client\n// @service client\npackage client\n",
+	})
+	files := tsFiles(t, projectDir)
+	// outputs of two runs must be same
+	for name, p := range tsFiles(t, projectDir) {
+		if !bytes.Equal(files[name], p) {
+			t.Fatalf("%s is not stable", name)
+		}
+	}
+	golden := filepath.Join("testdata", "ts")
+	if *update {
+		if err := os.RemoveAll(golden); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(golden, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for name, p := range files {
+			if err := os.WriteFile(filepath.Join(golden, name+".golden"), p, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	entries, readErr := os.ReadDir(golden)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	if len(names) != len(files) {
+		t.Errorf("got %d files, want %d golden files %v", len(files), len(names), names)
+	}
+	for _, name := range names {
+		want, err := os.ReadFile(filepath.Join(golden, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, has := files[name[:len(name)-len(".golden")]]
+		if !has {
+			t.Errorf("%s is not generated", name)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is different from golden file, run go test ./sdk -run TestTsGolden -update if it is expected:\n%s", name, got)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sources
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+const (
+	blockBegin = ">>>"
+	blockEnd   = "<<<"
+)
+
+// Annotation is a line starts with @ in doc comments, a block annotation takes lines between >>> and <<<.
+type Annotation struct {
+	Name  string
+	Value string
+	Block bool
+	Lines []string
+	Pos   token.Position
+}
+
+// Text returns the value of annotation, lines are joined for block annotation.
+func (annotation Annotation) Text() string {
+	if annotation.Block {
+		return strings.TrimSpace(strings.Join(annotation.Lines, "\n"))
+	}
+	return annotation.Value
+}
+
+type Annotations []Annotation
+
+// Get returns the first annotation named name.
+func (annotations Annotations) Get(name string) (annotation Annotation, has bool) {
+	for _, a := range annotations {
+		if a.Name == name {
+			annotation = a
+			has = true
+			return
+		}
+	}
+	return
+}
+
+// Has returns true when there is an annotation named name.
+func (annotations Annotations) Has(name string) bool {
+	_, has := annotations.Get(name)
+	return has
+}
+
+// Value returns the text of annotation named name.
+func (annotations Annotations) Value(name string) string {
+	annotation, has := annotations.Get(name)
+	if !has {
+		return ""
+	}
+	return annotation.Text()
+}
+
+// ParseAnnotations parses annotations of doc, problems are returned for unterminated blocks.
+func ParseAnnotations(fset *token.FileSet, doc *ast.CommentGroup) (annotations Annotations, problems Problems) {
+	annotations = make(Annotations, 0, 1)
+	if doc == nil {
+		return
+	}
+	var current *Annotation
+	for _, comment := range doc.List {
+		if strings.HasPrefix(comment.Text, "/*") {
+			continue
+		}
+		pos := fset.Position(comment.Slash)
+		text := strings.TrimPrefix(comment.Text, "//")
+		line := strings.TrimSpace(text)
+		if current != nil {
+			if line == blockEnd {
+				annotations = append(annotations, *current)
+				current = nil
+				continue
+			}
+			if strings.HasPrefix(line, "@") && isAnnotationName(line) {
+				problems = append(problems, Problem{Pos: current.Pos, Message: "@" + current.Name + " block is not terminated by " + blockEnd})
+				annotations = append(annotations, *current)
+				current = nil
+			} else {
+				current.Lines = append(current.Lines, strings.TrimPrefix(text, " "))
+				continue
+			}
+		}
+		if !strings.HasPrefix(line, "@") || !isAnnotationName(line) {
+			continue
+		}
+		name, value := line[1:], ""
+		if idx := strings.IndexAny(name, " \t"); idx > 0 {
+			name, value = name[:idx], strings.TrimSpace(name[idx+1:])
+		}
+		annotation := Annotation{
+			Name:  name,
+			Value: value,
+			Pos:   pos,
+		}
+		if value == blockBegin {
+			annotation.Value = ""
+			annotation.Block = true
+			annotation.Lines = make([]string, 0, 1)
+			current = &annotation
+			continue
+		}
+		annotations = append(annotations, annotation)
+	}
+	if current != nil {
+		problems = append(problems, Problem{Pos: current.Pos, Message: "@" + current.Name + " block is not terminated by " + blockEnd})
+		annotations = append(annotations, *current)
+	}
+	return
+}
+
+// isAnnotationName returns true when line starts with @ and a name, e.g. @fn, emails like @example.com are not names.
+func isAnnotationName(line string) bool {
+	if len(line) < 2 {
+		return false
+	}
+	c := line[1]
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// I18n is a text of language.
+type I18n struct {
+	Lang string
+	Text string
+}
+
+// ParseI18n parses lines like "zh: text" or "- zh: text".
+func ParseI18n(lines []string) (items []I18n, bad []string) {
+	items = make([]I18n, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "-"))
+		idx := strings.Index(line, ":")
+		if idx < 1 {
+			bad = append(bad, line)
+			continue
+		}
+		items = append(items, I18n{
+			Lang: strings.TrimSpace(line[:idx]),
+			Text: strings.TrimSpace(line[idx+1:]),
+		})
+	}
+	return
+}
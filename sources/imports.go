@@ -0,0 +1,176 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sources
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// importPath returns the import path of name in file, names of unnamed imports are read from package clauses of imported packages.
+func (loader *loader) importPath(file *ast.File, name string) string {
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		if spec.Name != nil {
+			if spec.Name.Name == name {
+				return importPath
+			}
+			continue
+		}
+		if loader.packageName(importPath) == name {
+			return importPath
+		}
+	}
+	return ""
+}
+
+// packageName returns the name in package clause of package, packages are found in project, vendor, replaced dirs, module cache and GOROOT.
+// the name is assumed by import path like goimports when the package is not found.
+func (loader *loader) packageName(importPath string) (name string) {
+	if cached, has := loader.names[importPath]; has {
+		name = cached
+		return
+	}
+	if p := loader.pkg(importPath); p != nil {
+		name = p.name
+	}
+	if name == "" {
+		for _, dir := range loader.packageDirs(importPath) {
+			if name = packageClause(dir); name != "" {
+				break
+			}
+		}
+	}
+	if name == "" {
+		name = assumedPackageName(importPath)
+	}
+	loader.names[importPath] = name
+	return
+}
+
+// packageDirs returns dirs where the package out of project may be placed.
+func (loader *loader) packageDirs(importPath string) (dirs []string) {
+	dirs = make([]string, 0, 2)
+	dirs = append(dirs, filepath.Join(loader.dir, "vendor", filepath.FromSlash(importPath)))
+	if first := strings.Split(importPath, "/")[0]; !strings.Contains(first, ".") {
+		goroot := os.Getenv("GOROOT")
+		if goroot == "" {
+			goroot = runtime.GOROOT()
+		}
+		dirs = append(dirs, filepath.Join(goroot, "src", filepath.FromSlash(importPath)))
+		return
+	}
+	if loader.mod == nil {
+		return
+	}
+	var required *modfile.Require
+	for _, require := range loader.mod.Require {
+		if (importPath == require.Mod.Path || strings.HasPrefix(importPath, require.Mod.Path+"/")) && (required == nil || len(require.Mod.Path) > len(required.Mod.Path)) {
+			required = require
+		}
+	}
+	if required == nil {
+		return
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(importPath, required.Mod.Path), "/")
+	target := required.Mod
+	for _, replace := range loader.mod.Replace {
+		if replace.Old.Path != target.Path || (replace.Old.Version != "" && replace.Old.Version != target.Version) {
+			continue
+		}
+		if replace.New.Version == "" {
+			root := filepath.FromSlash(replace.New.Path)
+			if !filepath.IsAbs(root) {
+				root = filepath.Join(loader.dir, root)
+			}
+			dirs = append(dirs, filepath.Join(root, filepath.FromSlash(rel)))
+			return
+		}
+		target = replace.New
+		break
+	}
+	escapedPath, pathErr := module.EscapePath(target.Path)
+	escapedVersion, versionErr := module.EscapeVersion(target.Version)
+	if pathErr != nil || versionErr != nil {
+		return
+	}
+	dirs = append(dirs, filepath.Join(modCacheDir(), filepath.FromSlash(escapedPath)+"@"+escapedVersion, filepath.FromSlash(rel)))
+	return
+}
+
+// modCacheDir returns the dir of module cache, it is GOMODCACHE or pkg/mod of GOPATH.
+func modCacheDir() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	gopath := filepath.SplitList(os.Getenv("GOPATH"))
+	if len(gopath) > 0 && gopath[0] != "" {
+		return filepath.Join(gopath[0], "pkg", "mod")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "go", "pkg", "mod")
+}
+
+// packageClause returns the package name of go files in dir, test files are skipped.
+func packageClause(dir string) string {
+	entries, readErr := os.ReadDir(dir)
+	if readErr != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".go" || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, parseErr := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, name), nil, parser.PackageClauseOnly)
+		if parseErr != nil || file.Name.Name == "main" || file.Name.Name == "documentation" {
+			continue
+		}
+		return file.Name.Name
+	}
+	return ""
+}
+
+// assumedPackageName returns the package name which is assumed by import path, it follows goimports,
+// the major version suffix and the go- prefix are removed, and the name ends at the first char which is not allowed in identifiers.
+func assumedPackageName(importPath string) string {
+	base := path.Base(importPath)
+	if strings.HasPrefix(base, "v") {
+		if _, err := strconv.Atoi(base[1:]); err == nil {
+			if dir := path.Dir(importPath); dir != "." {
+				base = path.Base(dir)
+			}
+		}
+	}
+	base = strings.TrimPrefix(base, "go-")
+	if i := strings.IndexFunc(base, func(c rune) bool {
+		return !(unicode.IsLetter(c) || c == '_' || unicode.IsDigit(c))
+	}); i >= 0 {
+		base = base[:i]
+	}
+	return base
+}
@@ -0,0 +1,46 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sources
+
+import (
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"sort"
+)
+
+// Problem is a diagnostic of sources, such as an unknown annotation.
+type Problem struct {
+	Pos     token.Position
+	Message string
+}
+
+func (problem Problem) String() string {
+	return fmt.Sprintf("%s:%d: %s", filepath.ToSlash(problem.Pos.Filename), problem.Pos.Line, problem.Message)
+}
+
+type Problems []Problem
+
+// Sort sorts problems by file and line.
+func (problems Problems) Sort() {
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Pos.Filename != problems[j].Pos.Filename {
+			return problems[i].Pos.Filename < problems[j].Pos.Filename
+		}
+		return problems[i].Pos.Line < problems[j].Pos.Line
+	})
+}
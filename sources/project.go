@@ -0,0 +1,581 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sources
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"golang.org/x/mod/modfile"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Project is services of a fns project which are parsed from sources, it does not require the project to be built.
+type Project struct {
+	Dir      string
+	Path     string
+	Services []*Service
+	// Problems are invalid annotations and declarations found when parsing
+	Problems Problems
+}
+
+// Service is a package under modules whose package doc has @service.
+type Service struct {
+	Name string
+	// Dir is slash separated and relative to project dir
+	Dir         string
+	Path        string
+	Package     string
+	Title       string
+	Description string
+	Internal    bool
+	Annotations Annotations
+	Fns         []*Fn
+	// Deps are dirs of packages in project which are imported by service directly or indirectly, they are slash separated and relative to project dir
+	Deps []string
+	Pos  token.Position
+}
+
+// Fn is a function with @fn in a service.
+type Fn struct {
+	Name string
+	// Ident is the go name of function
+	Ident         string
+	Title         string
+	Description   string
+	Timeout       time.Duration
+	Barrier       bool
+	Authorization bool
+	Permission    bool
+	Validation    bool
+	Deprecated    bool
+	Internal      bool
+	Errors        []FnError
+	// Argument is nil when fn has no argument
+	Argument *Type
+	// Result is nil when fn has no result
+	Result      *Type
+	Annotations Annotations
+	Pos         token.Position
+}
+
+// FnError is an error in @errors of fn.
+type FnError struct {
+	Name         string
+	Descriptions []I18n
+}
+
+var (
+	serviceAnnotations = []string{"service", "title", "description", "internal"}
+	fnAnnotations      = []string{"fn", "title", "description", "timeout", "barrier", "errors", "authorization", "permission", "validation", "deprecated", "internal", "transactional"}
+	typeAnnotations    = []string{"title", "description", "deprecated", "validate-message-i18n"}
+)
+
+// Load parses go.mod and services under modules of project dir.
+func Load(dir string) (project *Project, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return
+	}
+	modFilename := filepath.Join(dir, "go.mod")
+	p, readErr := os.ReadFile(modFilename)
+	if readErr != nil {
+		err = fmt.Errorf("read %s failed, %v", modFilename, readErr)
+		return
+	}
+	modPath := modfile.ModulePath(p)
+	if modPath == "" {
+		err = fmt.Errorf("module path of %s was not found", modFilename)
+		return
+	}
+	// requires and replaces are used to find package names of imports, it is optional
+	mod, _ := modfile.Parse(modFilename, p, nil)
+	loader := &loader{
+		fset:     token.NewFileSet(),
+		dir:      dir,
+		modPath:  modPath,
+		mod:      mod,
+		pkgs:     make(map[string]*pkg),
+		names:    make(map[string]string),
+		named:    make(map[string]*Type),
+		problems: make(Problems, 0, 1),
+	}
+	project = &Project{
+		Dir:      filepath.ToSlash(dir),
+		Path:     modPath,
+		Services: make([]*Service, 0, 1),
+	}
+	modulesDir := filepath.Join(dir, "modules")
+	walkErr := filepath.WalkDir(modulesDir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if os.IsNotExist(walkErr) {
+				return filepath.SkipDir
+			}
+			return walkErr
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != modulesDir && (strings.HasPrefix(d.Name(), ".") || d.Name() == "testdata") {
+			return filepath.SkipDir
+		}
+		rel, relErr := filepath.Rel(dir, path)
+		if relErr != nil {
+			return relErr
+		}
+		pkg := loader.pkg(modPath + "/" + filepath.ToSlash(rel))
+		if pkg == nil {
+			return nil
+		}
+		service := loader.service(pkg)
+		if service != nil {
+			service.Deps = loader.deps(pkg)
+			project.Services = append(project.Services, service)
+		}
+		return nil
+	})
+	if walkErr != nil {
+		err = fmt.Errorf("walk %s failed, %v", modulesDir, walkErr)
+		return
+	}
+	sort.Slice(project.Services, func(i, j int) bool {
+		return project.Services[i].Dir < project.Services[j].Dir
+	})
+	names := make(map[string]*Service)
+	for _, service := range project.Services {
+		if prev, has := names[service.Name]; has {
+			loader.problem(service.Pos, "service %s is duplicated, it is declared in %s", service.Name, prev.Pos.Filename)
+			continue
+		}
+		names[service.Name] = service
+	}
+	project.Problems = loader.problems
+	project.Problems.Sort()
+	return
+}
+
+// LoadValid loads the project like Load, but it fails when there are problems in annotations.
+func LoadValid(dir string) (project *Project, err error) {
+	project, err = Load(dir)
+	if err != nil {
+		return
+	}
+	if n := len(project.Problems); n > 0 {
+		lines := make([]string, 0, n)
+		for _, problem := range project.Problems {
+			lines = append(lines, problem.String())
+		}
+		err = fmt.Errorf("%d problems were found, see fnc lint\n%s", n, strings.Join(lines, "\n"))
+		project = nil
+		return
+	}
+	return
+}
+
+// Service returns the service named name.
+func (project *Project) Service(name string) (service *Service, has bool) {
+	for _, s := range project.Services {
+		if s.Name == name {
+			service = s
+			has = true
+			return
+		}
+	}
+	return
+}
+
+type pkg struct {
+	path  string
+	dir   string
+	name  string
+	files []*ast.File
+	types map[string]typeSpec
+}
+
+type typeSpec struct {
+	spec *ast.TypeSpec
+	doc  *ast.CommentGroup
+	file *ast.File
+}
+
+type loader struct {
+	fset    *token.FileSet
+	dir     string
+	modPath string
+	mod     *modfile.File
+	pkgs    map[string]*pkg
+	// names are package names of import paths
+	names    map[string]string
+	named    map[string]*Type
+	problems Problems
+}
+
+func (loader *loader) problem(pos token.Position, format string, args ...interface{}) {
+	loader.problems = append(loader.problems, Problem{Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// pkg parses the package of path, nil is returned when the package is not in project or has no go files.
+func (loader *loader) pkg(path string) *pkg {
+	if p, has := loader.pkgs[path]; has {
+		return p
+	}
+	loader.pkgs[path] = nil
+	if path != loader.modPath && !strings.HasPrefix(path, loader.modPath+"/") {
+		return nil
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(path, loader.modPath), "/")
+	dir := filepath.Join(loader.dir, filepath.FromSlash(rel))
+	entries, readErr := os.ReadDir(dir)
+	if readErr != nil {
+		return nil
+	}
+	p := &pkg{
+		path:  path,
+		dir:   dir,
+		types: make(map[string]typeSpec),
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".go" || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		filename := filepath.Join(dir, name)
+		src, srcErr := os.ReadFile(filename)
+		if srcErr != nil {
+			continue
+		}
+		display, _ := filepath.Rel(loader.dir, filename)
+		file, parseErr := parser.ParseFile(loader.fset, filepath.ToSlash(display), src, parser.ParseComments)
+		if parseErr != nil {
+			loader.problems = append(loader.problems, Problem{Pos: token.Position{Filename: filepath.ToSlash(display), Line: 1}, Message: parseErr.Error()})
+			continue
+		}
+		if p.name == "" {
+			p.name = file.Name.Name
+		}
+		if file.Name.Name != p.name {
+			continue
+		}
+		p.files = append(p.files, file)
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				doc := ts.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				p.types[ts.Name.Name] = typeSpec{spec: ts, doc: doc, file: file}
+			}
+		}
+	}
+	if len(p.files) == 0 {
+		return nil
+	}
+	loader.pkgs[path] = p
+	return p
+}
+
+// deps returns dirs of packages in project which are imported by pkg directly or indirectly, they are sorted.
+func (loader *loader) deps(root *pkg) (dirs []string) {
+	dirs = make([]string, 0, 1)
+	visited := map[string]bool{root.path: true}
+	queue := []*pkg{root}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, file := range p.files {
+			for _, spec := range file.Imports {
+				path, _ := strconv.Unquote(spec.Path.Value)
+				if visited[path] {
+					continue
+				}
+				visited[path] = true
+				dep := loader.pkg(path)
+				if dep == nil {
+					continue
+				}
+				dir := strings.TrimPrefix(strings.TrimPrefix(dep.path, loader.modPath), "/")
+				if dir == "" {
+					dir = "."
+				}
+				dirs = append(dirs, dir)
+				queue = append(queue, dep)
+			}
+		}
+	}
+	sort.Strings(dirs)
+	return
+}
+
+// service returns the service of pkg, nil is returned when pkg has no @service.
+func (loader *loader) service(pkg *pkg) (service *Service) {
+	for _, file := range pkg.files {
+		annotations, problems := ParseAnnotations(loader.fset, file.Doc)
+		if !annotations.Has("service") {
+			continue
+		}
+		loader.problems = append(loader.problems, problems...)
+		loader.checkNames(annotations, serviceAnnotations)
+		annotation, _ := annotations.Get("service")
+		name := strings.TrimSpace(annotation.Value)
+		if name == "" {
+			loader.problem(annotation.Pos, "@service requires a name")
+			name = pkg.name
+		}
+		service = &Service{
+			Name:        name,
+			Dir:         strings.TrimPrefix(strings.TrimPrefix(pkg.path, loader.modPath), "/"),
+			Path:        pkg.path,
+			Package:     pkg.name,
+			Title:       annotations.Value("title"),
+			Description: annotations.Value("description"),
+			Internal:    annotations.Has("internal"),
+			Annotations: annotations,
+			Fns:         make([]*Fn, 0, 1),
+			Pos:         annotation.Pos,
+		}
+		break
+	}
+	if service == nil {
+		return
+	}
+	names := make(map[string]*Fn)
+	for _, file := range pkg.files {
+		for _, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Recv != nil {
+				continue
+			}
+			annotations, problems := ParseAnnotations(loader.fset, fd.Doc)
+			if !annotations.Has("fn") {
+				continue
+			}
+			loader.problems = append(loader.problems, problems...)
+			fn := loader.fn(pkg, file, fd, annotations)
+			if prev, has := names[fn.Name]; has {
+				loader.problem(fn.Pos, "fn %s is duplicated, it is declared at %s:%d", fn.Name, prev.Pos.Filename, prev.Pos.Line)
+				continue
+			}
+			names[fn.Name] = fn
+			service.Fns = append(service.Fns, fn)
+		}
+	}
+	sort.Slice(service.Fns, func(i, j int) bool {
+		return service.Fns[i].Name < service.Fns[j].Name
+	})
+	return
+}
+
+func (loader *loader) fn(pkg *pkg, file *ast.File, fd *ast.FuncDecl, annotations Annotations) (fn *Fn) {
+	loader.checkNames(annotations, fnAnnotations)
+	annotation, _ := annotations.Get("fn")
+	fn = &Fn{
+		Name:          strings.TrimSpace(annotation.Value),
+		Ident:         fd.Name.Name,
+		Title:         annotations.Value("title"),
+		Description:   annotations.Value("description"),
+		Barrier:       annotations.Has("barrier"),
+		Authorization: annotations.Has("authorization"),
+		Permission:    annotations.Has("permission"),
+		Validation:    annotations.Has("validation"),
+		Deprecated:    annotations.Has("deprecated"),
+		Internal:      annotations.Has("internal"),
+		Annotations:   annotations,
+		Pos:           loader.fset.Position(fd.Pos()),
+	}
+	if fn.Name == "" {
+		loader.problem(annotation.Pos, "@fn requires a name")
+		fn.Name = fd.Name.Name
+	}
+	if timeout, has := annotations.Get("timeout"); has {
+		d, parseErr := time.ParseDuration(strings.TrimSpace(timeout.Value))
+		if parseErr != nil || d <= 0 {
+			loader.problem(timeout.Pos, "@timeout %q is not a valid duration, e.g. 1s or 500ms", timeout.Value)
+		} else {
+			fn.Timeout = d
+		}
+	}
+	if errs, has := annotations.Get("errors"); has {
+		fn.Errors = loader.fnErrors(errs)
+	}
+	loader.signature(pkg, file, fd, fn)
+	return
+}
+
+// fnErrors parses @errors block, "+ name" begins an error and "- lang: text" lines are its descriptions.
+func (loader *loader) fnErrors(annotation Annotation) (errs []FnError) {
+	if !annotation.Block {
+		loader.problem(annotation.Pos, "@errors must be a block which begins with %s and ends with %s", blockBegin, blockEnd)
+		return
+	}
+	errs = make([]FnError, 0, 1)
+	for i, line := range annotation.Lines {
+		line = strings.TrimSpace(line)
+		pos := annotation.Pos
+		pos.Line = pos.Line + i + 1
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "+"):
+			name := strings.TrimSpace(line[1:])
+			if name == "" {
+				loader.problem(pos, "@errors item requires a name")
+				continue
+			}
+			errs = append(errs, FnError{Name: name, Descriptions: make([]I18n, 0, 1)})
+		case strings.HasPrefix(line, "-"):
+			if len(errs) == 0 {
+				loader.problem(pos, "@errors description must follow an error name which begins with +")
+				continue
+			}
+			items, bad := ParseI18n([]string{line})
+			if len(bad) > 0 {
+				loader.problem(pos, "@errors description '%s' is not in '- lang: text' format", line)
+				continue
+			}
+			errs[len(errs)-1].Descriptions = append(errs[len(errs)-1].Descriptions, items...)
+		default:
+			loader.problem(pos, "@errors line '%s' must begin with + or -", line)
+		}
+	}
+	return
+}
+
+// signature checks that fn is func(ctx context.Context[, argument Argument]) ([result Result, ]err errors.CodeError).
+func (loader *loader) signature(pkg *pkg, file *ast.File, fd *ast.FuncDecl, fn *Fn) {
+	params := fieldTypes(fd.Type.Params)
+	results := fieldTypes(fd.Type.Results)
+	if fd.Type.TypeParams != nil && len(fd.Type.TypeParams.List) > 0 {
+		loader.problem(fn.Pos, "fn %s must not have type parameters", fn.Name)
+	}
+	if len(params) == 0 || len(params) > 2 || !loader.isSelector(file, params[0], "context", "Context") {
+		loader.problem(fn.Pos, "fn %s must be func(ctx context.Context[, argument Argument]) ([result Result, ]err errors.CodeError)", fn.Name)
+	} else if len(params) == 2 {
+		fn.Argument = loader.resolveExpr(pkg, file, params[1])
+		if !fn.Argument.Exported() || fn.Argument.Kind != StructKind {
+			loader.problem(fn.Pos, "argument of fn %s must be an exported struct", fn.Name)
+		}
+	}
+	if len(results) == 0 || len(results) > 2 || !loader.isSelector(file, results[len(results)-1], "github.com/aacfactory/errors", "CodeError") {
+		loader.problem(fn.Pos, "fn %s must return errors.CodeError as the last result", fn.Name)
+	} else if len(results) == 2 {
+		fn.Result = loader.resolveExpr(pkg, file, results[0])
+		if !fn.Result.Exported() {
+			loader.problem(fn.Pos, "result of fn %s must be an exported type", fn.Name)
+		}
+	}
+}
+
+// checkNames reports annotations which are not known.
+func (loader *loader) checkNames(annotations Annotations, known []string) {
+	for _, annotation := range annotations {
+		if strings.Contains(annotation.Name, ":") || containsString(known, annotation.Name) {
+			continue
+		}
+		message := fmt.Sprintf("unknown annotation @%s", annotation.Name)
+		if suggestion := closest(annotation.Name, known); suggestion != "" {
+			message = message + fmt.Sprintf(", did you mean @%s?", suggestion)
+		}
+		loader.problems = append(loader.problems, Problem{Pos: annotation.Pos, Message: message})
+	}
+}
+
+func fieldTypes(list *ast.FieldList) (types []ast.Expr) {
+	if list == nil {
+		return
+	}
+	for _, field := range list.List {
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			types = append(types, field.Type)
+		}
+	}
+	return
+}
+
+func (loader *loader) isSelector(file *ast.File, expr ast.Expr, path string, name string) bool {
+	se, ok := expr.(*ast.SelectorExpr)
+	if !ok || se.Sel.Name != name {
+		return false
+	}
+	ident, ok := se.X.(*ast.Ident)
+	return ok && loader.importPath(file, ident.Name) == path
+}
+
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+// closest returns the known name whose edit distance to name is less than 3.
+func closest(name string, known []string) (v string) {
+	best := 3
+	for _, k := range known {
+		if d := distance(name, k); d < best {
+			best = d
+			v = k
+		}
+	}
+	return
+}
+
+func distance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func min3(a int, b int, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,206 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testMod     = "module example.com/project\n\ngo 1.20\n"
+	testService = "// Package users\n// @service users\npackage users\n"
+	testImports = "package users\n\nimport (\n\t\"context\"\n\t\"github.com/aacfactory/errors\"\n)\n\n"
+)
+
+func writeTestProject(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadProblems(t *testing.T) {
+	cases := []struct {
+		name    string
+		service string
+		fns     string
+		problem string
+	}{
+		{
+			name:    "unknown annotation",
+			fns:     "// @fn get\n// @timout 1s\nfunc get(ctx context.Context) (err errors.CodeError) {\n\treturn\n}\n",
+			problem: "get.go:9: unknown annotation @timout, did you mean @timeout?",
+		},
+		{
+			name:    "invalid timeout",
+			fns:     "// @fn get\n// @timeout 1x\nfunc get(ctx context.Context) (err errors.CodeError) {\n\treturn\n}\n",
+			problem: "get.go:9: @timeout \"1x\" is not a valid duration, e.g. 1s or 500ms",
+		},
+		{
+			name:    "unterminated block",
+			fns:     "// @fn get\n// @errors >>>\n// + not_found\n// @barrier\nfunc get(ctx context.Context) (err errors.CodeError) {\n\treturn\n}\n",
+			problem: "get.go:9: @errors block is not terminated by <<<",
+		},
+		{
+			name:    "errors is not a block",
+			fns:     "// @fn get\n// @errors not_found\nfunc get(ctx context.Context) (err errors.CodeError) {\n\treturn\n}\n",
+			problem: "get.go:9: @errors must be a block which begins with >>> and ends with <<<",
+		},
+		{
+			name:    "errors item",
+			fns:     "// @fn get\n// @errors >>>\n// - en: not found\n// not_found\n// + not_found\n// - en\n// <<<\nfunc get(ctx context.Context) (err errors.CodeError) {\n\treturn\n}\n",
+			problem: "get.go:10: @errors description must follow an error name which begins with +\nget.go:11: @errors line 'not_found' must begin with + or -\nget.go:13: @errors description '- en' is not in '- lang: text' format",
+		},
+		{
+			name:    "service requires a name",
+			service: "// Package users\n// @service\npackage users\n",
+			fns:     "// @fn get\nfunc get(ctx context.Context) (err errors.CodeError) {\n\treturn\n}\n",
+			problem: "doc.go:2: @service requires a name",
+		},
+		{
+			name:    "fn requires a name",
+			fns:     "// @fn\nfunc get(ctx context.Context) (err errors.CodeError) {\n\treturn\n}\n",
+			problem: "get.go:8: @fn requires a name",
+		},
+		{
+			name:    "duplicate fn",
+			fns:     "// @fn get\nfunc get(ctx context.Context) (err errors.CodeError) {\n\treturn\n}\n\n// @fn get\nfunc get2(ctx context.Context) (err errors.CodeError) {\n\treturn\n}\n",
+			problem: "get.go:14: fn get is duplicated, it is declared at",
+		},
+		{
+			name:    "type parameters",
+			fns:     "// @fn get\nfunc get[T any](ctx context.Context) (err errors.CodeError) {\n\treturn\n}\n",
+			problem: "get.go:9: fn get must not have type parameters",
+		},
+		{
+			name:    "signature",
+			fns:     "// @fn get\nfunc get(argument string) (err errors.CodeError) {\n\treturn\n}\n",
+			problem: "get.go:9: fn get must be func(ctx context.Context[, argument Argument]) ([result Result, ]err errors.CodeError)",
+		},
+		{
+			name:    "argument",
+			fns:     "type argument struct{}\n\n// @fn get\nfunc get(ctx context.Context, argument argument) (err errors.CodeError) {\n\treturn\n}\n",
+			problem: "get.go:11: argument of fn get must be an exported struct",
+		},
+		{
+			name:    "result",
+			fns:     "type result struct{}\n\n// @fn get\nfunc get(ctx context.Context) (result *result, err errors.CodeError) {\n\treturn\n}\n",
+			problem: "get.go:11: result of fn get must be an exported type",
+		},
+		{
+			name:    "code error",
+			fns:     "// @fn get\nfunc get(ctx context.Context) (err error) {\n\treturn\n}\n",
+			problem: "get.go:9: fn get must return errors.CodeError as the last result",
+		},
+		{
+			name:    "validate message i18n",
+			fns:     "type Argument struct {\n\t// @validate-message-i18n >>>\n\t// en\n\t// <<<\n\tName string `json:\"name\" validate:\"required\" validate-message:\"name is required\"`\n}\n\n// @fn get\nfunc get(ctx context.Context, argument Argument) (err errors.CodeError) {\n\treturn\n}\n",
+			problem: "get.go:9: @validate-message-i18n line 'en' is not in 'lang: text' format",
+		},
+		{
+			name:    "parse error",
+			fns:     "func get( {\n",
+			problem: "get.go:1: ",
+		},
+	}
+	for _, c := range cases {
+		service := c.service
+		if service == "" {
+			service = testService
+		}
+		dir := writeTestProject(t, map[string]string{
+			"go.mod":               testMod,
+			"modules/users/doc.go": service,
+			"modules/users/get.go": testImports + c.fns,
+		})
+		project, err := Load(dir)
+		if err != nil {
+			t.Fatal(c.name, err)
+		}
+		lines := make([]string, 0, len(project.Problems))
+		for _, problem := range project.Problems {
+			lines = append(lines, strings.TrimPrefix(problem.String(), "modules/users/"))
+		}
+		if got := strings.Join(lines, "\n"); !strings.HasPrefix(got, c.problem) {
+			t.Errorf("%s: got problems\n%s\nwant\n%s", c.name, got, c.problem)
+		}
+	}
+}
+
+func TestLoadDuplicateService(t *testing.T) {
+	dir := writeTestProject(t, map[string]string{
+		"go.mod":                testMod,
+		"modules/users/doc.go":  testService,
+		"modules/people/doc.go": "// Package people\n// @service users\npackage people\n",
+	})
+	project, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(project.Problems) != 1 || !strings.Contains(project.Problems[0].Message, "service users is duplicated") {
+		t.Errorf("got problems %v", project.Problems)
+	}
+}
+
+func TestLoadPackageClause(t *testing.T) {
+	dir := writeTestProject(t, map[string]string{
+		"go.mod":                       "module example.com/project\n\ngo 1.20\n\nrequire example.com/kitten v1.0.0\n\nreplace example.com/kitten => ./third/kitten\n",
+		"third/kitten/go.mod":          "module example.com/kitten\n\ngo 1.20\n",
+		"third/kitten/kit.go":          "package kit\n\ntype Row struct{}\n",
+		"lib/helpers/helpers.go":       "package util\n\ntype Argument struct {\n\tName string `json:\"name\"`\n}\n",
+		"modules/users/doc.go":         testService,
+		"modules/users/get.go":         "package users\n\nimport (\n\t\"context\"\n\t\"example.com/kitten\"\n\t\"example.com/project/lib/helpers\"\n\t\"github.com/aacfactory/errors\"\n)\n\n// @fn get\nfunc get(ctx context.Context, argument util.Argument) (result *kit.Row, err errors.CodeError) {\n\treturn\n}\n",
+		"modules/users/get_test.go":    "package users_test\n",
+		"modules/users/testdata/x.txt": "",
+	})
+	project, err := LoadValid(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn := project.Services[0].Fns[0]
+	if fn.Argument.Path != "example.com/project/lib/helpers" || fn.Argument.Name != "Argument" || len(fn.Argument.Fields) != 1 {
+		t.Errorf("got argument %+v", fn.Argument)
+	}
+	if fn.Result.Path != "example.com/kitten" || fn.Result.Name != "Row" {
+		t.Errorf("got result %+v", fn.Result)
+	}
+}
+
+func TestAssumedPackageName(t *testing.T) {
+	cases := map[string]string{
+		"github.com/aacfactory/errors":  "errors",
+		"github.com/go-redis/redis/v9":  "redis",
+		"github.com/mattn/go-sqlite3":   "sqlite3",
+		"gopkg.in/yaml.v3":              "yaml",
+		"github.com/aacfactory/fns-kit": "fns",
+	}
+	for importPath, want := range cases {
+		if got := assumedPackageName(importPath); got != want {
+			t.Errorf("%s: got %s, want %s", importPath, got, want)
+		}
+	}
+}
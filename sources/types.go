@@ -0,0 +1,334 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sources
+
+import (
+	"go/ast"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type Kind string
+
+const (
+	StringKind   Kind = "string"
+	BoolKind     Kind = "bool"
+	IntKind      Kind = "int"
+	UintKind     Kind = "uint"
+	FloatKind    Kind = "float"
+	TimeKind     Kind = "time"
+	DateKind     Kind = "date"
+	DurationKind Kind = "duration"
+	BytesKind    Kind = "bytes"
+	AnyKind      Kind = "any"
+	StructKind   Kind = "struct"
+	ArrayKind    Kind = "array"
+	MapKind      Kind = "map"
+)
+
+// Type is a json type of go type, named types are shared, so they may be recursive.
+type Type struct {
+	Kind Kind
+	// Path is the package path of named type
+	Path string
+	// Name is the name of named type
+	Name string
+	// Bits is bits of int, uint and float, 0 means the platform size
+	Bits        int
+	Title       string
+	Description string
+	// Elem is the element of array and the value of map
+	Elem   *Type
+	Fields []*Field
+}
+
+// Named returns true when type is a named type.
+func (t *Type) Named() bool {
+	return t.Name != ""
+}
+
+// Exported returns true when type is a named and exported type.
+func (t *Type) Exported() bool {
+	return t.Named() && ast.IsExported(t.Name)
+}
+
+// Field is a json property of struct.
+type Field struct {
+	// Name is the go name of field
+	Name string
+	// Key is the json key
+	Key         string
+	Title       string
+	Description string
+	Omitempty   bool
+	// Validate is the validate tag
+	Validate string
+	// ValidateMessage is the validate-message tag
+	ValidateMessage string
+	// ValidateI18n are texts of @validate-message-i18n
+	ValidateI18n []I18n
+	Deprecated   bool
+	Type         *Type
+}
+
+// Required returns true when validate tag has required.
+func (field *Field) Required() bool {
+	for _, rule := range ValidateRules(field.Validate) {
+		if rule.Name == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateRule is a rule of validate tag, e.g. min=1.
+type ValidateRule struct {
+	Name  string
+	Param string
+}
+
+// ValidateRules splits validate tag into rules.
+func ValidateRules(tag string) (rules []ValidateRule) {
+	rules = make([]ValidateRule, 0, 1)
+	for _, item := range strings.Split(tag, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		rule := ValidateRule{Name: item}
+		if idx := strings.Index(item, "="); idx > 0 {
+			rule.Name, rule.Param = item[:idx], item[idx+1:]
+		}
+		rules = append(rules, rule)
+	}
+	return
+}
+
+var builtinTypes = map[string]*Type{
+	"string":     {Kind: StringKind},
+	"bool":       {Kind: BoolKind},
+	"int":        {Kind: IntKind},
+	"int8":       {Kind: IntKind, Bits: 8},
+	"int16":      {Kind: IntKind, Bits: 16},
+	"int32":      {Kind: IntKind, Bits: 32},
+	"rune":       {Kind: IntKind, Bits: 32},
+	"int64":      {Kind: IntKind, Bits: 64},
+	"uint":       {Kind: UintKind},
+	"uint8":      {Kind: UintKind, Bits: 8},
+	"byte":       {Kind: UintKind, Bits: 8},
+	"uint16":     {Kind: UintKind, Bits: 16},
+	"uint32":     {Kind: UintKind, Bits: 32},
+	"uint64":     {Kind: UintKind, Bits: 64},
+	"uintptr":    {Kind: UintKind, Bits: 64},
+	"float32":    {Kind: FloatKind, Bits: 32},
+	"float64":    {Kind: FloatKind, Bits: 64},
+	"any":        {Kind: AnyKind},
+	"error":      {Kind: AnyKind},
+	"complex64":  {Kind: AnyKind},
+	"complex128": {Kind: AnyKind},
+}
+
+// knownTypes are types out of project, keys are path.name.
+var knownTypes = map[string]*Type{
+	"time.Time":                                    {Kind: TimeKind},
+	"time.Duration":                                {Kind: DurationKind},
+	"encoding/json.RawMessage":                     {Kind: AnyKind},
+	"github.com/aacfactory/json.RawMessage":        {Kind: AnyKind},
+	"github.com/aacfactory/json.Date":              {Kind: DateKind},
+	"github.com/aacfactory/json.Time":              {Kind: TimeKind},
+	"github.com/aacfactory/fns/commons/times.Date": {Kind: DateKind},
+	"github.com/aacfactory/fns/commons/times.Time": {Kind: TimeKind},
+	"github.com/aacfactory/fns/service.Empty":      {Kind: StructKind, Path: "github.com/aacfactory/fns/service", Name: "Empty"},
+	"database/sql.NullString":                      {Kind: StringKind},
+	"database/sql.NullBool":                        {Kind: BoolKind},
+	"database/sql.NullInt64":                       {Kind: IntKind, Bits: 64},
+	"database/sql.NullInt32":                       {Kind: IntKind, Bits: 32},
+	"database/sql.NullFloat64":                     {Kind: FloatKind, Bits: 64},
+	"database/sql.NullTime":                        {Kind: TimeKind},
+}
+
+func copyType(t *Type) *Type {
+	v := *t
+	return &v
+}
+
+// resolveExpr resolves type expression in file of pkg.
+func (loader *loader) resolveExpr(pkg *pkg, file *ast.File, expr ast.Expr) *Type {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if t, has := builtinTypes[e.Name]; has {
+			if _, local := pkg.types[e.Name]; !local {
+				return copyType(t)
+			}
+		}
+		return loader.resolveNamed(pkg, e.Name)
+	case *ast.SelectorExpr:
+		ident, ok := e.X.(*ast.Ident)
+		if !ok {
+			return &Type{Kind: AnyKind}
+		}
+		path := loader.importPath(file, ident.Name)
+		if path == "" {
+			return &Type{Kind: AnyKind}
+		}
+		if t, has := knownTypes[path+"."+e.Sel.Name]; has {
+			return copyType(t)
+		}
+		target := loader.pkg(path)
+		if target == nil {
+			return &Type{Kind: AnyKind, Path: path, Name: e.Sel.Name}
+		}
+		return loader.resolveNamed(target, e.Sel.Name)
+	case *ast.StarExpr:
+		return loader.resolveExpr(pkg, file, e.X)
+	case *ast.ParenExpr:
+		return loader.resolveExpr(pkg, file, e.X)
+	case *ast.ArrayType:
+		if ident, ok := e.Elt.(*ast.Ident); ok && (ident.Name == "byte" || ident.Name == "uint8") && e.Len == nil {
+			return &Type{Kind: BytesKind}
+		}
+		return &Type{Kind: ArrayKind, Elem: loader.resolveExpr(pkg, file, e.Elt)}
+	case *ast.MapType:
+		return &Type{Kind: MapKind, Elem: loader.resolveExpr(pkg, file, e.Value)}
+	case *ast.StructType:
+		t := &Type{Kind: StructKind}
+		t.Fields = loader.resolveFields(pkg, file, e)
+		return t
+	default:
+		return &Type{Kind: AnyKind}
+	}
+}
+
+// resolveNamed resolves named type of pkg, results are cached, so recursive types are shared.
+func (loader *loader) resolveNamed(pkg *pkg, name string) *Type {
+	key := pkg.path + "." + name
+	if t, has := loader.named[key]; has {
+		return t
+	}
+	spec, has := pkg.types[name]
+	if !has {
+		return &Type{Kind: AnyKind, Path: pkg.path, Name: name}
+	}
+	t := &Type{Path: pkg.path, Name: name}
+	loader.named[key] = t
+	annotations, problems := ParseAnnotations(loader.fset, spec.doc)
+	loader.problems = append(loader.problems, problems...)
+	loader.checkNames(annotations, typeAnnotations)
+	t.Title = annotations.Value("title")
+	t.Description = annotations.Value("description")
+	if spec.spec.TypeParams != nil && len(spec.spec.TypeParams.List) > 0 {
+		t.Kind = AnyKind
+		return t
+	}
+	resolved := loader.resolveExpr(pkg, spec.file, spec.spec.Type)
+	t.Kind = resolved.Kind
+	t.Bits = resolved.Bits
+	t.Elem = resolved.Elem
+	t.Fields = resolved.Fields
+	if resolved.Named() && t.Title == "" {
+		t.Title = resolved.Title
+		t.Description = resolved.Description
+	}
+	return t
+}
+
+// resolveFields resolves json properties of struct, fields of embedded structs without json name are promoted.
+func (loader *loader) resolveFields(pkg *pkg, file *ast.File, st *ast.StructType) (fields []*Field) {
+	fields = make([]*Field, 0, len(st.Fields.List))
+	for _, f := range st.Fields.List {
+		tag := reflect.StructTag("")
+		if f.Tag != nil {
+			if value, unquoteErr := strconv.Unquote(f.Tag.Value); unquoteErr == nil {
+				tag = reflect.StructTag(value)
+			}
+		}
+		jsonTag, hasJsonTag := tag.Lookup("json")
+		if jsonTag == "-" {
+			continue
+		}
+		key, options := jsonTag, ""
+		if idx := strings.Index(jsonTag, ","); idx >= 0 {
+			key, options = jsonTag[:idx], jsonTag[idx+1:]
+		}
+		if len(f.Names) == 0 {
+			// embedded
+			ft := loader.resolveExpr(pkg, file, f.Type)
+			if (!hasJsonTag || key == "") && ft.Kind == StructKind {
+				fields = append(fields, ft.Fields...)
+				continue
+			}
+			if key == "" {
+				key = embeddedName(f.Type)
+			}
+			if !ast.IsExported(embeddedName(f.Type)) {
+				continue
+			}
+			fields = append(fields, loader.newField(embeddedName(f.Type), key, options, tag, f, ft))
+			continue
+		}
+		for _, name := range f.Names {
+			if !name.IsExported() {
+				continue
+			}
+			fieldKey := key
+			if fieldKey == "" {
+				fieldKey = name.Name
+			}
+			fields = append(fields, loader.newField(name.Name, fieldKey, options, tag, f, loader.resolveExpr(pkg, file, f.Type)))
+		}
+	}
+	return
+}
+
+func (loader *loader) newField(name string, key string, options string, tag reflect.StructTag, f *ast.Field, ft *Type) *Field {
+	field := &Field{
+		Name:            name,
+		Key:             key,
+		Omitempty:       strings.Contains(","+options+",", ",omitempty,"),
+		Validate:        tag.Get("validate"),
+		ValidateMessage: tag.Get("validate-message"),
+		Type:            ft,
+	}
+	annotations, problems := ParseAnnotations(loader.fset, f.Doc)
+	loader.problems = append(loader.problems, problems...)
+	loader.checkNames(annotations, typeAnnotations)
+	field.Title = annotations.Value("title")
+	field.Description = annotations.Value("description")
+	field.Deprecated = annotations.Has("deprecated")
+	if annotation, has := annotations.Get("validate-message-i18n"); has {
+		items, bad := ParseI18n(annotation.Lines)
+		field.ValidateI18n = items
+		for _, line := range bad {
+			loader.problems = append(loader.problems, Problem{Pos: annotation.Pos, Message: "@validate-message-i18n line '" + line + "' is not in 'lang: text' format"})
+		}
+	}
+	return field
+}
+
+func embeddedName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.StarExpr:
+		return embeddedName(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	default:
+		return ""
+	}
+}
@@ -19,6 +19,7 @@ package ssc
 import (
 	"github.com/aacfactory/errors"
 	"github.com/urfave/cli/v2"
+	"net"
 	"strings"
 )
 
@@ -29,19 +30,103 @@ var Command = &cli.Command{
 	Description: "create self signed ca",
 	ArgsUsage:   "",
 	Category:    "",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
+		&cli.BoolFlag{
+			Required: false,
+			Name:     "full",
+			Value:    false,
+			Usage:    "create all tls files",
+		},
+		&cli.IntFlag{
+			Required: false,
+			Name:     "intermediates",
+			Value:    0,
+			Usage:    "number of intermediate cas between root ca and issued certificates",
+		},
 		&cli.StringFlag{
 			Required: false,
-			Name:     "cn",
+			Name:     "format",
+			Value:    pemFormat,
+			Usage:    "extra output formats, p12, k8s-secret or all, use comma to join multiple formats, pem files are always written",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "p12-pass",
 			Value:    "",
-			Usage:    "common name",
+			Usage:    "password of p12 files",
+			EnvVars:  []string{"FNC_P12_PASS"},
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "secret-name",
+			Value:    "",
+			Usage:    "name prefix of kubernetes secrets, default is derived from common name",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "namespace",
+			Value:    "",
+			Usage:    "namespace of kubernetes secrets",
 		},
 		&cli.BoolFlag{
 			Required: false,
-			Name:     "full",
+			Name:     "force",
 			Value:    false,
-			Usage:    "create all tls files",
+			Usage:    "overwrite existing files",
+		},
+		keyPassFlag(),
+		&cli.StringFlag{
+			Required: false,
+			Name:     "project",
+			Value:    "",
+			Usage:    "fns project dir, http tls of its config is set to generated files, --full is required",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "env",
+			Value:    "",
+			Usage:    "config env of project, local, dev, test or prod, empty means fns.yaml",
 		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "client-auth",
+			Value:    "require-and-verify",
+			Usage:    "mtls mode written into project config, " + strings.Join(clientAuthModes, ", "),
+		},
+		&cli.StringSliceFlag{
+			Required: false,
+			Name:     "server-dns",
+			Usage:    "dns name of server certificate only, repeatable",
+		},
+		&cli.StringSliceFlag{
+			Required: false,
+			Name:     "server-ip",
+			Usage:    "ip address of server certificate only, repeatable",
+		},
+		&cli.StringSliceFlag{
+			Required: false,
+			Name:     "client-dns",
+			Usage:    "dns name of client certificate only, repeatable",
+		},
+		&cli.StringSliceFlag{
+			Required: false,
+			Name:     "client-ip",
+			Usage:    "ip address of client certificate only, repeatable",
+		},
+		&cli.StringSliceFlag{
+			Required: false,
+			Name:     "client-email",
+			Usage:    "email address of client certificate only, repeatable",
+		},
+	}, certificateFlags()...),
+	Subcommands: []*cli.Command{
+		signCommand,
+		inspectCommand,
+		verifyCommand,
+		renewCommand,
+		expiringCommand,
+		listCommand,
+		revokeCommand,
 	},
 	Action: func(ctx *cli.Context) (err error) {
 		cn := ctx.String("cn")
@@ -54,7 +139,65 @@ var Command = &cli.Command{
 			err = errors.Warning("fnc: create ssc failed").WithCause(errors.Warning("output is undefined"))
 			return
 		}
-		err = generate(cn, full, outputDir)
+		values, flagsErr := certificateFlagValues(ctx, cn)
+		if flagsErr != nil {
+			err = errors.Warning("fnc: create ssc failed").WithCause(flagsErr)
+			return
+		}
+		intermediates := ctx.Int("intermediates")
+		if intermediates < 0 {
+			err = errors.Warning("fnc: create ssc failed").WithCause(errors.Warning("intermediates is invalid")).WithMeta("intermediates", ctx.String("intermediates"))
+			return
+		}
+		formats, formatsErr := parseFormats([]string{ctx.String("format")})
+		if formatsErr != nil {
+			err = errors.Warning("fnc: create ssc failed").WithCause(formatsErr).WithMeta("format", ctx.String("format"))
+			return
+		}
+		formats.P12Password = ctx.String("p12-pass")
+		if formats.P12 && formats.P12Password == "" {
+			err = errors.Warning("fnc: create ssc failed").WithCause(errors.Warning("p12-pass is required when p12 format is used"))
+			return
+		}
+		formats.SecretName = secretName(ctx.String("secret-name"))
+		if ctx.String("secret-name") == "" {
+			formats.SecretName = secretName(cn)
+		}
+		formats.Namespace = strings.TrimSpace(ctx.String("namespace"))
+		serverIPs := append(ctx.StringSlice("server-ip"), values.Names.IPs...)
+		clientIPs := append(ctx.StringSlice("client-ip"), values.Names.IPs...)
+		ipsErr := checkIPs(append(serverIPs, clientIPs...))
+		if ipsErr != nil {
+			err = errors.Warning("fnc: create ssc failed").WithCause(ipsErr)
+			return
+		}
+		project, projectErr := projectFlagValues(ctx, full)
+		if projectErr != nil {
+			err = errors.Warning("fnc: create ssc failed").WithCause(projectErr)
+			return
+		}
+		opt := options{
+			Subject:       values.Subject,
+			Days:          values.Days,
+			Full:          full,
+			Intermediates: intermediates,
+			Key:           values.Key,
+			Formats:       formats,
+			KeyPass:       ctx.String("key-pass"),
+			Force:         ctx.Bool("force"),
+			Project:       project,
+			Server: alternativeNames{
+				IPs:      serverIPs,
+				Emails:   values.Names.Emails,
+				DNSNames: append(ctx.StringSlice("server-dns"), values.Names.DNSNames...),
+			},
+			Client: alternativeNames{
+				IPs:      clientIPs,
+				Emails:   append(ctx.StringSlice("client-email"), values.Names.Emails...),
+				DNSNames: append(ctx.StringSlice("client-dns"), values.Names.DNSNames...),
+			},
+		}
+		err = generate(opt, outputDir)
 		if err != nil {
 			err = errors.Warning("fnc: create ssc failed").WithCause(err)
 			return
@@ -62,3 +205,188 @@ var Command = &cli.Command{
 		return
 	},
 }
+
+// certificateFlags returns flags of certificate subject, alternative names and validity, they are shared by ssc commands.
+func certificateFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Required: false,
+			Name:     "cn",
+			Value:    "",
+			Usage:    "common name",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "org",
+			Value:    "",
+			Usage:    "organization",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "ou",
+			Value:    "",
+			Usage:    "organizational unit",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "country",
+			Value:    "",
+			Usage:    "country",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "province",
+			Value:    "",
+			Usage:    "province",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "city",
+			Value:    "",
+			Usage:    "city",
+		},
+		&cli.IntFlag{
+			Required: false,
+			Name:     "days",
+			Value:    365,
+			Usage:    "validity days of certificates",
+		},
+		&cli.StringSliceFlag{
+			Required: false,
+			Name:     "dns",
+			Usage:    "dns name of issued certificates, repeatable",
+		},
+		&cli.StringSliceFlag{
+			Required: false,
+			Name:     "ip",
+			Usage:    "ip address of issued certificates, repeatable",
+		},
+		&cli.StringSliceFlag{
+			Required: false,
+			Name:     "email",
+			Usage:    "email address of issued certificates, repeatable",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "key-type",
+			Value:    rsaKeyType,
+			Usage:    "key algorithm, rsa, ecdsa or ed25519",
+		},
+		&cli.IntFlag{
+			Required: false,
+			Name:     "key-size",
+			Value:    4096,
+			Usage:    "key bits of rsa",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "curve",
+			Value:    "P-256",
+			Usage:    "curve of ecdsa, P-256, P-384 or P-521",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "key-format",
+			Value:    pkcs1KeyFormat,
+			Usage:    "private key format, pkcs1 or pkcs8, pkcs1 means SEC 1 for ecdsa, ed25519 keys are pkcs8 by default",
+		},
+	}
+}
+
+// keyPassFlag returns the flag of passphrase which encrypts and decrypts ca private keys.
+func keyPassFlag() cli.Flag {
+	return &cli.StringFlag{
+		Required: false,
+		Name:     "key-pass",
+		Value:    "",
+		Usage:    "passphrase of ca private keys, keys of issued certificates are not encrypted",
+		EnvVars:  []string{"FNC_KEY_PASS"},
+	}
+}
+
+type certificateFlagsValue struct {
+	Subject subject
+	Days    int
+	Names   alternativeNames
+	Key     keyOptions
+}
+
+func certificateFlagValues(ctx *cli.Context, cn string) (v certificateFlagsValue, err error) {
+	days := ctx.Int("days")
+	if days < 1 {
+		err = errors.Warning("days is invalid").WithMeta("days", ctx.String("days"))
+		return
+	}
+	v.Days = days
+	v.Subject = subject{
+		Country:            ctx.String("country"),
+		Province:           ctx.String("province"),
+		City:               ctx.String("city"),
+		Organization:       ctx.String("org"),
+		OrganizationalUnit: ctx.String("ou"),
+		CommonName:         cn,
+	}
+	v.Names = alternativeNames{
+		IPs:      ctx.StringSlice("ip"),
+		Emails:   ctx.StringSlice("email"),
+		DNSNames: ctx.StringSlice("dns"),
+	}
+	err = checkIPs(v.Names.IPs)
+	if err != nil {
+		return
+	}
+	v.Key = keyOptions{
+		Type:   strings.TrimSpace(strings.ToLower(ctx.String("key-type"))),
+		Size:   ctx.Int("key-size"),
+		Curve:  ctx.String("curve"),
+		Format: strings.TrimSpace(strings.ToLower(ctx.String("key-format"))),
+	}
+	// ed25519 keys can not be encoded as pkcs1, so pkcs8 is used unless the format is set explicitly
+	if v.Key.Type == ed25519KeyType && !ctx.IsSet("key-format") {
+		v.Key.Format = pkcs8KeyFormat
+	}
+	err = v.Key.check()
+	if err != nil {
+		return
+	}
+	return
+}
+
+func projectFlagValues(ctx *cli.Context, full bool) (v projectOptions, err error) {
+	v.Dir = strings.TrimSpace(ctx.String("project"))
+	if v.Dir == "" {
+		return
+	}
+	if !full {
+		err = errors.Warning("--full is required when project is set")
+		return
+	}
+	v.Env = strings.TrimSpace(strings.ToLower(ctx.String("env")))
+	if strings.ContainsAny(v.Env, `/\`) {
+		err = errors.Warning("env is invalid").WithMeta("env", v.Env)
+		return
+	}
+	v.ClientAuth = strings.TrimSpace(strings.ToLower(ctx.String("client-auth")))
+	valid := false
+	for _, mode := range clientAuthModes {
+		if mode == v.ClientAuth {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		err = errors.Warning("client-auth is invalid").WithMeta("client-auth", v.ClientAuth)
+		return
+	}
+	return
+}
+
+func checkIPs(ips []string) (err error) {
+	for _, ip := range ips {
+		if net.ParseIP(strings.TrimSpace(ip)) == nil {
+			err = errors.Warning("ip is invalid").WithMeta("ip", ip)
+			return
+		}
+	}
+	return
+}
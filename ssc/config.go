@@ -0,0 +1,200 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ssc
+
+import (
+	"fmt"
+	"github.com/goccy/go-yaml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var clientAuthModes = []string{"no", "request", "require-any", "verify-if-given", "require-and-verify"}
+
+// tlsConfig is the http.tls block of fns config, it has same layout of files.TLSConfig.
+type tlsConfig struct {
+	Cert       string `yaml:"cert,omitempty"`
+	Key        string `yaml:"key,omitempty"`
+	ClientCA   string `yaml:"clientCA,omitempty"`
+	ClientAuth string `yaml:"clientAuth,omitempty"`
+}
+
+type projectOptions struct {
+	Dir        string
+	Env        string
+	ClientAuth string
+}
+
+// configFilename returns the config file of env in project, an empty env means the default fns.yaml.
+func configFilename(projectDir string, env string) string {
+	name := "fns.yaml"
+	if env != "" {
+		name = fmt.Sprintf("fns-%s.yaml", env)
+	}
+	return filepath.Join(projectDir, "configs", name)
+}
+
+// writeProjectConfig sets http.tls of the env config in project to server files in outputDir.
+func writeProjectConfig(opt projectOptions, outputDir string, certFile string, keyFile string, caFile string) (err error) {
+	rel := func(name string) string {
+		return filepath.ToSlash(relativeFilename(opt.Dir, filepath.Join(outputDir, name)))
+	}
+	config := tlsConfig{
+		Cert:       rel(certFile),
+		Key:        rel(keyFile),
+		ClientCA:   rel(caFile),
+		ClientAuth: opt.ClientAuth,
+	}
+	if opt.ClientAuth == "no" {
+		config.ClientCA = ""
+	}
+	filename := configFilename(opt.Dir, opt.Env)
+	src, readErr := ioutil.ReadFile(filename)
+	if readErr != nil && !os.IsNotExist(readErr) {
+		err = fmt.Errorf("fnc: read %s failed, %v", filename, readErr)
+		return
+	}
+	dst, setErr := setTLSConfig(string(src), config)
+	if setErr != nil {
+		err = fmt.Errorf("fnc: update %s failed, %v", filename, setErr)
+		return
+	}
+	// make sure that the result is still valid and has the tls block
+	result := struct {
+		Http struct {
+			TLS tlsConfig `yaml:"tls"`
+		} `yaml:"http"`
+	}{}
+	if decodeErr := yaml.Unmarshal([]byte(dst), &result); decodeErr != nil || result.Http.TLS != config {
+		err = fmt.Errorf("fnc: update %s failed, http block can not be updated, please set tls manually", filename)
+		return
+	}
+	err = writeFiles("", []outputFile{{name: filename, content: []byte(dst)}}, true)
+	return
+}
+
+// setTLSConfig inserts or replaces the http.tls block of yaml source, other lines are kept as they are.
+func setTLSConfig(src string, config tlsConfig) (dst string, err error) {
+	p, encodeErr := yaml.Marshal(config)
+	if encodeErr != nil {
+		err = encodeErr
+		return
+	}
+	block := strings.Split(strings.TrimRight(string(p), "\n"), "\n")
+	lines := make([]string, 0, 1)
+	if src != "" {
+		lines = strings.Split(strings.TrimRight(src, "\n"), "\n")
+	}
+	httpIdx := -1
+	for i, line := range lines {
+		if key, value, ok := yamlKey(line); ok && indentOf(line) == 0 && key == "http" {
+			if value != "" {
+				err = fmt.Errorf("http block must be a block mapping")
+				return
+			}
+			httpIdx = i
+			break
+		}
+	}
+	if httpIdx < 0 {
+		lines = append(lines, "http:", "  tls:")
+		lines = append(lines, indentLines(block, "    ")...)
+		dst = strings.Join(lines, "\n") + "\n"
+		return
+	}
+	// children of http
+	end := len(lines)
+	indent := ""
+	tlsIdx := -1
+	for i := httpIdx + 1; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		if indentOf(line) == 0 {
+			end = i
+			break
+		}
+		if indent == "" {
+			indent = line[:indentOf(line)]
+		}
+		if key, _, ok := yamlKey(line); ok && line[:indentOf(line)] == indent && key == "tls" {
+			tlsIdx = i
+		}
+	}
+	if indent == "" {
+		indent = "  "
+	}
+	tls := append([]string{indent + "tls:"}, indentLines(block, indent+indent)...)
+	if tlsIdx < 0 {
+		// append as the last child of http
+		last := httpIdx
+		for i := httpIdx + 1; i < end; i++ {
+			if strings.TrimSpace(lines[i]) != "" && indentOf(lines[i]) > 0 {
+				last = i
+			}
+		}
+		dst = strings.Join(append(append(append([]string{}, lines[:last+1]...), tls...), lines[last+1:]...), "\n") + "\n"
+		return
+	}
+	// replace existing tls and its children
+	tlsEnd := end
+	for i := tlsIdx + 1; i < end; i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		if indentOf(lines[i]) <= len(indent) {
+			tlsEnd = i
+			break
+		}
+	}
+	dst = strings.Join(append(append(append([]string{}, lines[:tlsIdx]...), tls...), lines[tlsEnd:]...), "\n") + "\n"
+	return
+}
+
+// yamlKey returns key and inline value of a block mapping line, comments are removed from value.
+func yamlKey(line string) (key string, value string, ok bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "-") {
+		return
+	}
+	idx := strings.Index(trimmed, ":")
+	if idx < 1 || (idx+1 < len(trimmed) && trimmed[idx+1] != ' ' && trimmed[idx+1] != '\t') {
+		return
+	}
+	key = strings.Trim(trimmed[:idx], `"'`)
+	value = strings.TrimSpace(trimmed[idx+1:])
+	if i := strings.Index(value, "#"); i == 0 || (i > 0 && (value[i-1] == ' ' || value[i-1] == '\t')) {
+		value = strings.TrimSpace(value[:i])
+	}
+	ok = true
+	return
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+func indentLines(lines []string, indent string) []string {
+	v := make([]string, 0, len(lines))
+	for _, line := range lines {
+		v = append(v, indent+line)
+	}
+	return v
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ssc
+
+import (
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/urfave/cli/v2"
+	"io/fs"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var expiringCommand = &cli.Command{
+	Name:        "expiring",
+	Aliases:     nil,
+	Usage:       "fnc ssc expiring --within 30d .",
+	Description: "scan pem files in dir and report certificates which are close to expiry, exit with non-zero when any is found",
+	ArgsUsage:   "",
+	Category:    "",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Required: false,
+			Name:     "within",
+			Value:    "30d",
+			Usage:    "report certificates which expire within the duration, e.g. 30d, 72h",
+		},
+	},
+	Action: func(ctx *cli.Context) (err error) {
+		dir := strings.TrimSpace(ctx.Args().First())
+		if dir == "" {
+			dir = "."
+		}
+		within, withinErr := parseDays(ctx.String("within"))
+		if withinErr != nil {
+			err = errors.Warning("fnc: scan expiring ssc failed").WithCause(withinErr).WithMeta("within", ctx.String("within"))
+			return
+		}
+		now := time.Now()
+		items, scanErr := scanExpiring(dir, now.Add(within))
+		if scanErr != nil {
+			err = errors.Warning("fnc: scan expiring ssc failed").WithCause(scanErr).WithMeta("dir", dir)
+			return
+		}
+		if len(items) == 0 {
+			fmt.Println(fmt.Sprintf("fnc: no certificate expires within %s", ctx.String("within")))
+			return
+		}
+		for _, item := range items {
+			state := fmt.Sprintf("expires in %d days", int(item.NotAfter.Sub(now).Hours()/24))
+			if now.After(item.NotAfter) {
+				state = "EXPIRED"
+			}
+			fmt.Println(fmt.Sprintf("%s: %s, serial %s, not after %s, %s", item.Filename, item.Subject, item.SerialNumber, item.NotAfter.Format(time.RFC3339), state))
+		}
+		err = cli.Exit(fmt.Sprintf("fnc: %d certificates expire within %s", len(items), ctx.String("within")), 1)
+		return
+	},
+}
+
+type expiringCertificate struct {
+	Filename     string
+	Subject      string
+	SerialNumber string
+	NotAfter     time.Time
+}
+
+// scanExpiring walks dir and returns certificates which expire before deadline, files without certificates are skipped.
+func scanExpiring(dir string, deadline time.Time) (items []expiringCertificate, err error) {
+	items = make([]expiringCertificate, 0, 1)
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".crt", ".cer", ".cert", ".pem":
+			break
+		default:
+			return nil
+		}
+		p, readErr := ioutil.ReadFile(path)
+		if readErr != nil {
+			return readErr
+		}
+		certs, decodeErr := decodeCertificates(p)
+		if decodeErr != nil {
+			return fmt.Errorf("%s: %v", path, decodeErr)
+		}
+		for _, cert := range certs {
+			if cert.NotAfter.After(deadline) {
+				continue
+			}
+			items = append(items, expiringCertificate{
+				Filename:     path,
+				Subject:      cert.Subject.String(),
+				SerialNumber: colonHex(cert.SerialNumber.Bytes()),
+				NotAfter:     cert.NotAfter,
+			})
+		}
+		return nil
+	})
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].NotAfter.Before(items[j].NotAfter)
+	})
+	return
+}
+
+// parseDays parses duration which supports d as days unit, e.g. 30d.
+func parseDays(s string) (d time.Duration, err error) {
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, "d") {
+		days, daysErr := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if daysErr != nil || days < 0 {
+			err = fmt.Errorf("fnc: %s is invalid duration", s)
+			return
+		}
+		d = time.Duration(days) * 24 * time.Hour
+		return
+	}
+	d, err = time.ParseDuration(s)
+	if err != nil || d < 0 {
+		err = fmt.Errorf("fnc: %s is invalid duration", s)
+		return
+	}
+	return
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ssc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type outputFile struct {
+	name    string
+	content []byte
+}
+
+// writeFiles writes files into dir, dirs are created when they do not exist, names of files are relative to dir.
+// Existing files are kept unless force is true. All files are written into temp files first and then renamed,
+// so an interrupted run never leaves half-written files.
+func writeFiles(dir string, files []outputFile, force bool) (err error) {
+	for _, file := range files {
+		mkdirErr := os.MkdirAll(filepath.Dir(filepath.Join(dir, file.name)), 0700)
+		if mkdirErr != nil {
+			err = fmt.Errorf("fnc: create output dir failed, %v", mkdirErr)
+			return
+		}
+	}
+	if !force {
+		for _, file := range files {
+			filename := filepath.Join(dir, file.name)
+			if _, statErr := os.Stat(filename); statErr == nil {
+				err = fmt.Errorf("fnc: %s is exist, use --force to overwrite it", filename)
+				return
+			}
+		}
+	}
+	temps := make([]string, 0, len(files))
+	defer func() {
+		for _, temp := range temps {
+			_ = os.Remove(temp)
+		}
+	}()
+	for _, file := range files {
+		temp, tempErr := writeTempFile(filepath.Dir(filepath.Join(dir, file.name)), file)
+		if tempErr != nil {
+			err = tempErr
+			return
+		}
+		temps = append(temps, temp)
+	}
+	for i, file := range files {
+		renameErr := os.Rename(temps[i], filepath.Join(dir, file.name))
+		if renameErr != nil {
+			err = fmt.Errorf("fnc: write %s failed, %v", file.name, renameErr)
+			return
+		}
+	}
+	temps = temps[:0]
+	return
+}
+
+func writeTempFile(dir string, file outputFile) (name string, err error) {
+	f, createErr := os.CreateTemp(dir, "."+filepath.Base(file.name)+".*.tmp")
+	if createErr != nil {
+		err = fmt.Errorf("fnc: write %s failed, %v", file.name, createErr)
+		return
+	}
+	name = f.Name()
+	_, err = f.Write(file.content)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = f.Chmod(0600)
+	}
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(name)
+		err = fmt.Errorf("fnc: write %s failed, %v", file.name, err)
+		return
+	}
+	return
+}
@@ -0,0 +1,215 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ssc
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"github.com/goccy/go-yaml"
+	"regexp"
+	"strings"
+)
+
+const (
+	pemFormat    = "pem"
+	p12Format    = "p12"
+	secretFormat = "k8s-secret"
+	allFormat    = "all"
+)
+
+// formatOptions are extra formats of outputs, pem files are always written because they are the signing material of
+// sign, renew and revoke.
+type formatOptions struct {
+	P12         bool
+	Secret      bool
+	P12Password string
+	SecretName  string
+	Namespace   string
+}
+
+func parseFormats(formats []string) (opt formatOptions, err error) {
+	for _, format := range formats {
+		for _, item := range strings.Split(format, ",") {
+			switch strings.TrimSpace(strings.ToLower(item)) {
+			case pemFormat:
+				// pem files are always written
+				break
+			case p12Format:
+				opt.P12 = true
+				break
+			case secretFormat:
+				opt.Secret = true
+				break
+			case allFormat:
+				opt.P12, opt.Secret = true, true
+				break
+			default:
+				err = fmt.Errorf("fnc: format must be pem, p12, k8s-secret or all")
+				return
+			}
+		}
+	}
+	return
+}
+
+var secretNameCleaner = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// secretName returns a valid kubernetes resource name of common name.
+func secretName(cn string) string {
+	name := strings.Trim(secretNameCleaner.ReplaceAllString(strings.ToLower(cn), "-"), "-")
+	if name == "" {
+		name = "fns"
+	}
+	return name
+}
+
+type material struct {
+	name string
+	crt  []byte
+	key  []byte
+}
+
+// formatFiles returns p12 and kubernetes secret files of ca and leaves, leaves are issued by the last cert in chain.
+// The private key of ca is never exported by them, they carry the certificate of ca only.
+func formatFiles(opt formatOptions, ca []byte, chain []byte, leaves []material) (files []outputFile, err error) {
+	files = make([]outputFile, 0, 1)
+	if opt.P12 {
+		var p12 []outputFile
+		p12, err = p12Files(opt, ca, chain, leaves)
+		if err != nil {
+			return
+		}
+		files = append(files, p12...)
+	}
+	if opt.Secret {
+		var secrets []outputFile
+		secrets, err = secretFiles(opt, ca, chain, leaves)
+		if err != nil {
+			return
+		}
+		files = append(files, secrets...)
+	}
+	return
+}
+
+func p12Files(opt formatOptions, ca []byte, chain []byte, leaves []material) (files []outputFile, err error) {
+	caCert, caCertErr := parseCertificate(ca)
+	if caCertErr != nil {
+		err = caCertErr
+		return
+	}
+	intermediates, intermediatesErr := decodeCertificates(chain)
+	if intermediatesErr != nil {
+		err = intermediatesErr
+		return
+	}
+	files = make([]outputFile, 0, 1+len(leaves))
+	trustStore, trustStoreErr := encodePKCS12TrustStore([]*x509.Certificate{caCert}, caUsage, opt.P12Password)
+	if trustStoreErr != nil {
+		err = trustStoreErr
+		return
+	}
+	files = append(files, outputFile{name: "truststore.p12", content: trustStore})
+	for _, leaf := range leaves {
+		cert, certErr := parseCertificate(leaf.crt)
+		if certErr != nil {
+			err = certErr
+			return
+		}
+		key, keyErr := parsePrivateKey(leaf.key, "")
+		if keyErr != nil {
+			err = keyErr
+			return
+		}
+		p, encodeErr := encodePKCS12(key, cert, append(append([]*x509.Certificate{}, intermediates...), caCert), opt.P12Password)
+		if encodeErr != nil {
+			err = encodeErr
+			return
+		}
+		files = append(files, outputFile{name: leaf.name + ".p12", content: p})
+	}
+	return
+}
+
+type kubernetesSecret struct {
+	APIVersion string                   `yaml:"apiVersion"`
+	Kind       string                   `yaml:"kind"`
+	Metadata   kubernetesSecretMetadata `yaml:"metadata"`
+	Type       string                   `yaml:"type"`
+	Data       kubernetesSecretData     `yaml:"data"`
+}
+
+type kubernetesSecretMetadata struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+type kubernetesSecretData struct {
+	CA  string `yaml:"ca.crt,omitempty"`
+	Crt string `yaml:"tls.crt,omitempty"`
+	Key string `yaml:"tls.key,omitempty"`
+}
+
+func secretFiles(opt formatOptions, ca []byte, chain []byte, leaves []material) (files []outputFile, err error) {
+	files = make([]outputFile, 0, 1+len(leaves))
+	// the secret of ca is a trust bundle, so it is an opaque secret which has ca.crt only
+	caSecret, caSecretErr := encodeSecret(opt, caUsage, nil, nil, ca)
+	if caSecretErr != nil {
+		err = caSecretErr
+		return
+	}
+	files = append(files, outputFile{name: caUsage + "-secret.yaml", content: caSecret})
+	for _, leaf := range leaves {
+		p, encodeErr := encodeSecret(opt, leaf.name, concat(leaf.crt, chain), leaf.key, ca)
+		if encodeErr != nil {
+			err = encodeErr
+			return
+		}
+		files = append(files, outputFile{name: leaf.name + "-secret.yaml", content: p})
+	}
+	return
+}
+
+func encodeSecret(opt formatOptions, name string, crt []byte, key []byte, ca []byte) (p []byte, err error) {
+	secret := kubernetesSecret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: kubernetesSecretMetadata{
+			Name:      fmt.Sprintf("%s-%s", opt.SecretName, name),
+			Namespace: opt.Namespace,
+		},
+		Type: "kubernetes.io/tls",
+		Data: kubernetesSecretData{
+			Crt: base64.StdEncoding.EncodeToString(crt),
+			Key: base64.StdEncoding.EncodeToString(key),
+		},
+	}
+	if len(key) == 0 {
+		secret.Type = "Opaque"
+		secret.Data.Crt, secret.Data.Key = "", ""
+	}
+	if len(ca) > 0 {
+		secret.Data.CA = base64.StdEncoding.EncodeToString(ca)
+	}
+	p, err = yaml.Marshal(secret)
+	if err != nil {
+		err = fmt.Errorf("fnc: encode kubernetes secret failed, %v", err)
+		return
+	}
+	return
+}
@@ -17,89 +17,332 @@
 package ssc
 
 import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
 	"fmt"
-	"github.com/aacfactory/afssl"
-	"io/ioutil"
-	"path/filepath"
+	"math/big"
+	"net"
+	"strings"
+	"time"
 )
 
-func generate(cn string, full bool, outputDir string) (err error) {
-	ca, caKey, caErr := create(cn, nil, nil)
+type subject struct {
+	Country            string
+	Province           string
+	City               string
+	Organization       string
+	OrganizationalUnit string
+	CommonName         string
+}
+
+type alternativeNames struct {
+	IPs      []string
+	Emails   []string
+	DNSNames []string
+}
+
+type options struct {
+	Subject       subject
+	Days          int
+	Full          bool
+	Intermediates int
+	Key           keyOptions
+	Formats       formatOptions
+	KeyPass       string
+	Force         bool
+	Project       projectOptions
+	Server        alternativeNames
+	Client        alternativeNames
+}
+
+func generate(opt options, outputDir string) (err error) {
+	files := make([]outputFile, 0, 8)
+	ca, caKey, caErr := create(opt.Subject, alternativeNames{}, opt.Days, true, nil, opt.Key, nil, nil)
 	if caErr != nil {
+		err = caErr
 		return
 	}
-	var serverCrt, serverKey []byte
-	var clientCrt, clientKey []byte
-	if full {
-		serverCrt, serverKey, err = create(cn, ca, caKey)
+	caKeyFile, caKeyFileErr := protectKey(caKey, opt.KeyPass)
+	if caKeyFileErr != nil {
+		err = caKeyFileErr
+		return
+	}
+	files = append(files, outputFile{name: "ca.crt", content: ca}, outputFile{name: "ca.key", content: caKeyFile})
+	issued := []material{{name: caUsage, crt: ca}}
+	// intermediates, the last one signs leaves
+	issuer, issuerKey := ca, caKey
+	chain := make([]byte, 0, 1)
+	for i := 1; i <= opt.Intermediates; i++ {
+		sub := opt.Subject
+		sub.CommonName = fmt.Sprintf("%s Intermediate CA %d", opt.Subject.CommonName, i)
+		issuer, issuerKey, err = create(sub, alternativeNames{}, opt.Days, true, nil, opt.Key, issuer, issuerKey)
 		if err != nil {
 			return
 		}
-		clientCrt, clientKey, err = create(cn, ca, caKey)
-		if err != nil {
+		issuerKeyFile, issuerKeyFileErr := protectKey(issuerKey, opt.KeyPass)
+		if issuerKeyFileErr != nil {
+			err = issuerKeyFileErr
 			return
 		}
+		files = append(
+			files,
+			outputFile{name: fmt.Sprintf("intermediate-%d.crt", i), content: issuer},
+			outputFile{name: fmt.Sprintf("intermediate-%d.key", i), content: issuerKeyFile},
+		)
+		chain = concat(issuer, chain)
+		issued = append(issued, material{name: fmt.Sprintf("intermediate-%d", i), crt: issuer})
 	}
-	err = ioutil.WriteFile(filepath.Join(outputDir, "ca.crt"), ca, 0600)
-	if err != nil {
-		err = fmt.Errorf("fnc: create ssc failed, %v", err)
+	if opt.Intermediates > 0 {
+		files = append(files, outputFile{name: "chain.pem", content: chain})
+	}
+	leaves := make([]material, 0, 2)
+	if opt.Full {
+		serverCrt, serverKey, serverErr := create(opt.Subject.leaf(opt.Server, serverUsage), opt.Server, opt.Days, false, extKeyUsagesOf(serverUsage), opt.Key, issuer, issuerKey)
+		if serverErr != nil {
+			err = serverErr
+			return
+		}
+		clientCrt, clientKey, clientErr := create(opt.Subject.leaf(opt.Client, clientUsage), opt.Client, opt.Days, false, extKeyUsagesOf(clientUsage), opt.Key, issuer, issuerKey)
+		if clientErr != nil {
+			err = clientErr
+			return
+		}
+		files = append(
+			files,
+			outputFile{name: "server.crt", content: serverCrt},
+			outputFile{name: "server.key", content: serverKey},
+			outputFile{name: "client.crt", content: clientCrt},
+			outputFile{name: "client.key", content: clientKey},
+		)
+		if opt.Intermediates > 0 {
+			files = append(
+				files,
+				outputFile{name: "fullchain.pem", content: concat(serverCrt, chain)},
+				outputFile{name: "client-fullchain.pem", content: concat(clientCrt, chain)},
+			)
+		}
+		leaves = append(leaves, material{name: serverUsage, crt: serverCrt, key: serverKey}, material{name: clientUsage, crt: clientCrt, key: clientKey})
+		issued = append(issued, leaves...)
+	}
+	formatted, formatErr := formatFiles(opt.Formats, ca, chain, leaves)
+	if formatErr != nil {
+		err = formatErr
+		return
+	}
+	files = append(files, formatted...)
+	// inventory, the one of previous ca is replaced because its certificates are not trusted by new ca
+	inv := &inventory{Certificates: make([]inventoryEntry, 0, len(issued))}
+	for _, item := range issued {
+		usage := item.name
+		if strings.HasPrefix(usage, intermediateUsage) {
+			usage = intermediateUsage
+		}
+		if err = inv.add(item.crt, usage, item.name+".crt", item.name+".key"); err != nil {
+			return
+		}
+	}
+	invFile, invErr := inv.file("")
+	if invErr != nil {
+		err = invErr
 		return
 	}
-	err = ioutil.WriteFile(filepath.Join(outputDir, "ca.key"), caKey, 0600)
+	files = append(files, invFile)
+	err = writeFiles(outputDir, files, opt.Force)
 	if err != nil {
-		err = fmt.Errorf("fnc: create ssc failed, %v", err)
 		return
 	}
-	if full {
-		err = ioutil.WriteFile(filepath.Join(outputDir, "server.crt"), serverCrt, 0600)
-		if err != nil {
-			err = fmt.Errorf("fnc: create ssc failed, %v", err)
-			return
+	if opt.Project.Dir != "" {
+		certFile := "server.crt"
+		if opt.Intermediates > 0 {
+			certFile = "fullchain.pem"
 		}
-		err = ioutil.WriteFile(filepath.Join(outputDir, "server.key"), serverKey, 0600)
+		err = writeProjectConfig(opt.Project, outputDir, certFile, "server.key", "ca.crt")
 		if err != nil {
-			err = fmt.Errorf("fnc: create ssc failed, %v", err)
 			return
 		}
-		err = ioutil.WriteFile(filepath.Join(outputDir, "client.crt"), clientCrt, 0600)
+	}
+	return
+}
+
+// protectKey encrypts key by password, key is returned as it is when password is empty.
+func protectKey(key []byte, password string) (p []byte, err error) {
+	if password == "" {
+		p = key
+		return
+	}
+	p, err = encryptPrivateKey(key, password)
+	return
+}
+
+// create issues a certificate by crypto/x509 directly, it is signed by parent, or self-signed when parent is empty.
+// afssl is not used because it generates rsa keys only, accepts unencrypted pkcs1 rsa parents only, sets both server and client
+// auth into every certificate and its cas can not sign crls, so key types, key formats, encrypted ca keys, usages and revocation
+// can not be supported by it.
+// extKeyUsages is ignored when isCA is true.
+func create(sub subject, names alternativeNames, days int, isCA bool, extKeyUsages []x509.ExtKeyUsage, keyOpt keyOptions, parent []byte, parentKey []byte) (cert []byte, key []byte, err error) {
+	priv, keyErr := keyOpt.generate()
+	if keyErr != nil {
+		err = keyErr
+		return
+	}
+	serialNumber, snErr := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if snErr != nil {
+		err = fmt.Errorf("fnc: create ssc failed, rand serial number failed, %v", snErr)
+		return
+	}
+	ips := make([]net.IP, 0, 1)
+	for _, ip := range trimNames(names.IPs) {
+		ips = append(ips, net.ParseIP(ip))
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               sub.name(),
+		NotBefore:             now.Add(-24 * time.Hour),
+		NotAfter:              now.Add(time.Duration(days) * 24 * time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		DNSNames:              trimNames(names.DNSNames),
+		EmailAddresses:        trimNames(names.Emails),
+		IPAddresses:           ips,
+	}
+	template.SubjectKeyId, err = subjectKeyId(priv.Public())
+	if err != nil {
+		return
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	} else {
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		if _, ok := priv.(*rsa.PrivateKey); ok {
+			template.KeyUsage = template.KeyUsage | x509.KeyUsageKeyEncipherment
+		}
+		template.ExtKeyUsage = extKeyUsages
+	}
+	issuer := template
+	var issuerKey crypto.Signer = priv
+	if len(parent) > 0 {
+		issuer, err = parseCertificate(parent)
 		if err != nil {
-			err = fmt.Errorf("fnc: create ssc failed, %v", err)
 			return
 		}
-		err = ioutil.WriteFile(filepath.Join(outputDir, "client.key"), clientKey, 0600)
+		issuerKey, err = parsePrivateKey(parentKey, "")
 		if err != nil {
-			err = fmt.Errorf("fnc: create ssc failed, %v", err)
 			return
 		}
 	}
+	der, createErr := x509.CreateCertificate(rand.Reader, template, issuer, priv.Public(), issuerKey)
+	if createErr != nil {
+		err = fmt.Errorf("fnc: create ssc failed, %v", createErr)
+		return
+	}
+	cert = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	key, err = encodePrivateKey(priv, keyOpt.Format)
+	if err != nil {
+		return
+	}
 	return
 }
 
-func create(cn string, ca []byte, caKey []byte) (cert []byte, key []byte, err error) {
-	config := afssl.CertificateConfig{
-		Country:            "",
-		Province:           "",
-		City:               "",
-		Organization:       "",
-		OrganizationalUnit: "",
-		CommonName:         cn,
-		IPs:                nil,
-		Emails:             nil,
-		DNSNames:           nil,
-	}
-	if ca == nil || len(ca) == 0 {
-		// ca
-		cert, key, err = afssl.GenerateCertificate(config, afssl.CA())
-		if err != nil {
-			err = fmt.Errorf("fnc: create ssc failed")
-			return
-		}
+// extKeyUsagesOf returns ext key usages of leaf usage, server or client.
+func extKeyUsagesOf(usage string) []x509.ExtKeyUsage {
+	if usage == clientUsage {
+		return []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+	return []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+}
+
+// leaf returns the subject of a leaf which is issued by the ca of sub, the common name of ca is not shared with leaves,
+// it is the first dns name of leaf, or the usage when there is no dns name.
+func (sub subject) leaf(names alternativeNames, usage string) (v subject) {
+	v = sub
+	v.CommonName = usage
+	if dnsNames := trimNames(names.DNSNames); len(dnsNames) > 0 {
+		v.CommonName = dnsNames[0]
+	}
+	return
+}
+
+func (sub subject) name() (name pkix.Name) {
+	name.CommonName = strings.TrimSpace(sub.CommonName)
+	if v := strings.TrimSpace(sub.Country); v != "" {
+		name.Country = []string{v}
+	}
+	if v := strings.TrimSpace(sub.Province); v != "" {
+		name.Province = []string{v}
+	}
+	if v := strings.TrimSpace(sub.City); v != "" {
+		name.Locality = []string{v}
+	}
+	if v := strings.TrimSpace(sub.Organization); v != "" {
+		name.Organization = []string{v}
+	}
+	if v := strings.TrimSpace(sub.OrganizationalUnit); v != "" {
+		name.OrganizationalUnit = []string{v}
+	}
+	return
+}
+
+// subjectKeyId is sha1 of subject public key, see RFC 5280 section 4.2.1.2.
+func subjectKeyId(pub crypto.PublicKey) (id []byte, err error) {
+	der, derErr := x509.MarshalPKIXPublicKey(pub)
+	if derErr != nil {
+		err = fmt.Errorf("fnc: create ssc failed, %v", derErr)
 		return
 	}
-	cert, key, err = afssl.GenerateCertificate(config, afssl.WithParent(ca, caKey))
-	if err != nil {
-		err = fmt.Errorf("fnc: create ssc failed")
+	var info struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err = asn1.Unmarshal(der, &info); err != nil {
+		err = fmt.Errorf("fnc: create ssc failed, %v", err)
 		return
 	}
+	sum := sha1.Sum(info.PublicKey.Bytes)
+	id = sum[:]
+	return
+}
+
+// concat joins pem blocks into a new bundle.
+func concat(items ...[]byte) (v []byte) {
+	size := 0
+	for _, item := range items {
+		size += len(item)
+	}
+	v = make([]byte, 0, size)
+	for _, item := range items {
+		v = append(v, item...)
+	}
+	return
+}
+
+// trimNames trims names and removes empty or duplicated items.
+func trimNames(names []string) (v []string) {
+	if len(names) == 0 {
+		return
+	}
+	v = make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		exist := false
+		for _, n := range v {
+			if n == name {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			v = append(v, name)
+		}
+	}
 	return
 }
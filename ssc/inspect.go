@@ -0,0 +1,256 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ssc
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/urfave/cli/v2"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+var inspectCommand = &cli.Command{
+	Name:        "inspect",
+	Aliases:     nil,
+	Usage:       "fnc ssc inspect server.crt",
+	Description: "print details of certificates in a pem file",
+	ArgsUsage:   "",
+	Category:    "",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Required: false,
+			Name:     "json",
+			Value:    false,
+			Usage:    "print as json",
+		},
+	},
+	Action: func(ctx *cli.Context) (err error) {
+		filename := strings.TrimSpace(ctx.Args().First())
+		if filename == "" {
+			err = errors.Warning("fnc: inspect ssc failed").WithCause(errors.Warning("file is undefined"))
+			return
+		}
+		certs, readErr := readCertificates(filename)
+		if readErr != nil {
+			err = errors.Warning("fnc: inspect ssc failed").WithCause(readErr).WithMeta("file", filename)
+			return
+		}
+		infos := make([]certificateInfo, 0, len(certs))
+		for _, cert := range certs {
+			infos = append(infos, newCertificateInfo(cert))
+		}
+		if ctx.Bool("json") {
+			p, encodeErr := json.MarshalIndent(infos, "", "  ")
+			if encodeErr != nil {
+				err = errors.Warning("fnc: inspect ssc failed").WithCause(encodeErr).WithMeta("file", filename)
+				return
+			}
+			fmt.Println(string(p))
+			return
+		}
+		for i, info := range infos {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Print(info.String())
+		}
+		return
+	},
+}
+
+type certificateInfo struct {
+	Subject           string    `json:"subject"`
+	Issuer            string    `json:"issuer"`
+	SerialNumber      string    `json:"serialNumber"`
+	IsCA              bool      `json:"isCA"`
+	KeyType           string    `json:"keyType"`
+	DNSNames          []string  `json:"dnsNames"`
+	IPs               []string  `json:"ips"`
+	Emails            []string  `json:"emails"`
+	KeyUsages         []string  `json:"keyUsages"`
+	ExtKeyUsages      []string  `json:"extKeyUsages"`
+	NotBefore         time.Time `json:"notBefore"`
+	NotAfter          time.Time `json:"notAfter"`
+	SHA1Fingerprint   string    `json:"sha1Fingerprint"`
+	SHA256Fingerprint string    `json:"sha256Fingerprint"`
+}
+
+func newCertificateInfo(cert *x509.Certificate) (info certificateInfo) {
+	info = certificateInfo{
+		Subject:           cert.Subject.String(),
+		Issuer:            cert.Issuer.String(),
+		SerialNumber:      colonHex(cert.SerialNumber.Bytes()),
+		IsCA:              cert.IsCA,
+		KeyType:           keyType(cert),
+		DNSNames:          make([]string, 0, len(cert.DNSNames)),
+		IPs:               make([]string, 0, len(cert.IPAddresses)),
+		Emails:            make([]string, 0, len(cert.EmailAddresses)),
+		KeyUsages:         keyUsages(cert.KeyUsage),
+		ExtKeyUsages:      extKeyUsages(cert.ExtKeyUsage),
+		NotBefore:         cert.NotBefore,
+		NotAfter:          cert.NotAfter,
+		SHA1Fingerprint:   "",
+		SHA256Fingerprint: "",
+	}
+	info.DNSNames = append(info.DNSNames, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		info.IPs = append(info.IPs, ip.String())
+	}
+	info.Emails = append(info.Emails, cert.EmailAddresses...)
+	sha1Sum := sha1.Sum(cert.Raw)
+	info.SHA1Fingerprint = colonHex(sha1Sum[:])
+	sha256Sum := sha256.Sum256(cert.Raw)
+	info.SHA256Fingerprint = colonHex(sha256Sum[:])
+	return
+}
+
+func (info certificateInfo) String() string {
+	b := strings.Builder{}
+	_, _ = fmt.Fprintf(&b, "Subject:            %s\n", info.Subject)
+	_, _ = fmt.Fprintf(&b, "Issuer:             %s\n", info.Issuer)
+	_, _ = fmt.Fprintf(&b, "Serial Number:      %s\n", info.SerialNumber)
+	_, _ = fmt.Fprintf(&b, "CA:                 %v\n", info.IsCA)
+	_, _ = fmt.Fprintf(&b, "Key Type:           %s\n", info.KeyType)
+	_, _ = fmt.Fprintf(&b, "DNS Names:          %s\n", strings.Join(info.DNSNames, ", "))
+	_, _ = fmt.Fprintf(&b, "IP Addresses:       %s\n", strings.Join(info.IPs, ", "))
+	_, _ = fmt.Fprintf(&b, "Emails:             %s\n", strings.Join(info.Emails, ", "))
+	_, _ = fmt.Fprintf(&b, "Key Usages:         %s\n", strings.Join(info.KeyUsages, ", "))
+	_, _ = fmt.Fprintf(&b, "Ext Key Usages:     %s\n", strings.Join(info.ExtKeyUsages, ", "))
+	_, _ = fmt.Fprintf(&b, "Not Before:         %s\n", info.NotBefore.Format(time.RFC3339))
+	_, _ = fmt.Fprintf(&b, "Not After:          %s\n", info.NotAfter.Format(time.RFC3339))
+	_, _ = fmt.Fprintf(&b, "SHA1 Fingerprint:   %s\n", info.SHA1Fingerprint)
+	_, _ = fmt.Fprintf(&b, "SHA256 Fingerprint: %s\n", info.SHA256Fingerprint)
+	return b.String()
+}
+
+// readCertificates reads all certificates in a pem file, the order of them is kept.
+func readCertificates(filename string) (certs []*x509.Certificate, err error) {
+	p, readErr := ioutil.ReadFile(filename)
+	if readErr != nil {
+		err = fmt.Errorf("fnc: read certificates failed, %v", readErr)
+		return
+	}
+	certs, err = decodeCertificates(p)
+	if err != nil {
+		return
+	}
+	if len(certs) == 0 {
+		err = fmt.Errorf("fnc: read certificates failed, no certificate was found in %s", filename)
+		return
+	}
+	return
+}
+
+// decodeCertificates decodes certificate blocks of pem content, other blocks are skipped.
+func decodeCertificates(p []byte) (certs []*x509.Certificate, err error) {
+	certs = make([]*x509.Certificate, 0, 1)
+	for {
+		var block *pem.Block
+		block, p = pem.Decode(p)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, parseErr := x509.ParseCertificate(block.Bytes)
+		if parseErr != nil {
+			err = fmt.Errorf("fnc: decode certificates failed, %v", parseErr)
+			return
+		}
+		certs = append(certs, cert)
+	}
+	return
+}
+
+func keyType(cert *x509.Certificate) string {
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", pub.N.BitLen())
+	case *ecdsa.PublicKey:
+		return fmt.Sprintf("ECDSA %s", pub.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return cert.PublicKeyAlgorithm.String()
+	}
+}
+
+func keyUsages(usage x509.KeyUsage) (v []string) {
+	names := []string{
+		"Digital Signature", "Content Commitment", "Key Encipherment", "Data Encipherment",
+		"Key Agreement", "Cert Sign", "CRL Sign", "Encipher Only", "Decipher Only",
+	}
+	v = make([]string, 0, 1)
+	for i, name := range names {
+		if usage&(1<<uint(i)) != 0 {
+			v = append(v, name)
+		}
+	}
+	return
+}
+
+func extKeyUsages(usages []x509.ExtKeyUsage) (v []string) {
+	v = make([]string, 0, len(usages))
+	for _, usage := range usages {
+		switch usage {
+		case x509.ExtKeyUsageAny:
+			v = append(v, "Any")
+			break
+		case x509.ExtKeyUsageServerAuth:
+			v = append(v, "Server Auth")
+			break
+		case x509.ExtKeyUsageClientAuth:
+			v = append(v, "Client Auth")
+			break
+		case x509.ExtKeyUsageCodeSigning:
+			v = append(v, "Code Signing")
+			break
+		case x509.ExtKeyUsageEmailProtection:
+			v = append(v, "Email Protection")
+			break
+		case x509.ExtKeyUsageTimeStamping:
+			v = append(v, "Time Stamping")
+			break
+		case x509.ExtKeyUsageOCSPSigning:
+			v = append(v, "OCSP Signing")
+			break
+		default:
+			v = append(v, fmt.Sprintf("Unknown(%d)", usage))
+			break
+		}
+	}
+	return
+}
+
+func colonHex(p []byte) string {
+	items := make([]string, 0, len(p))
+	for _, b := range p {
+		items = append(items, fmt.Sprintf("%02X", b))
+	}
+	return strings.Join(items, ":")
+}
@@ -0,0 +1,174 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ssc
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	inventoryFilename = "index.json"
+)
+
+const (
+	caUsage           = "ca"
+	intermediateUsage = "intermediate"
+	serverUsage       = "server"
+	clientUsage       = "client"
+)
+
+// inventory is the index of certificates which are issued by fnc, it is stored as index.json next to the ca.
+type inventory struct {
+	CRLNumber    int64            `json:"crlNumber"`
+	Certificates []inventoryEntry `json:"certificates"`
+}
+
+type inventoryEntry struct {
+	SerialNumber     string     `json:"serialNumber"`
+	Subject          string     `json:"subject"`
+	Issuer           string     `json:"issuer"`
+	RawIssuer        []byte     `json:"rawIssuer"`
+	Usage            string     `json:"usage"`
+	DNSNames         []string   `json:"dnsNames"`
+	IPs              []string   `json:"ips"`
+	Emails           []string   `json:"emails"`
+	IssuedAt         time.Time  `json:"issuedAt"`
+	ExpiresAt        time.Time  `json:"expiresAt"`
+	CertFile         string     `json:"certFile,omitempty"`
+	KeyFile          string     `json:"keyFile,omitempty"`
+	Revoked          bool       `json:"revoked"`
+	RevokedAt        *time.Time `json:"revokedAt,omitempty"`
+	RevocationReason string     `json:"revocationReason,omitempty"`
+}
+
+// loadInventory reads index.json in dir, an empty inventory is returned when it does not exist.
+func loadInventory(dir string) (inv *inventory, err error) {
+	inv = &inventory{
+		CRLNumber:    0,
+		Certificates: make([]inventoryEntry, 0, 1),
+	}
+	p, readErr := ioutil.ReadFile(filepath.Join(dir, inventoryFilename))
+	if readErr != nil {
+		if os.IsNotExist(readErr) {
+			return
+		}
+		err = fmt.Errorf("fnc: read inventory failed, %v", readErr)
+		return
+	}
+	if decodeErr := json.Unmarshal(p, inv); decodeErr != nil {
+		err = fmt.Errorf("fnc: decode %s failed, %v", filepath.Join(dir, inventoryFilename), decodeErr)
+		return
+	}
+	return
+}
+
+// add records cert, certFile and keyFile are relative to the dir of inventory.
+func (inv *inventory) add(certPEM []byte, usage string, certFile string, keyFile string) (err error) {
+	cert, parseErr := parseCertificate(certPEM)
+	if parseErr != nil {
+		err = parseErr
+		return
+	}
+	names := alternativeNamesOf(cert)
+	inv.Certificates = append(inv.Certificates, inventoryEntry{
+		SerialNumber: colonHex(cert.SerialNumber.Bytes()),
+		Subject:      cert.Subject.String(),
+		Issuer:       cert.Issuer.String(),
+		RawIssuer:    cert.RawIssuer,
+		Usage:        usage,
+		DNSNames:     append(make([]string, 0, len(names.DNSNames)), names.DNSNames...),
+		IPs:          names.IPs,
+		Emails:       append(make([]string, 0, len(names.Emails)), names.Emails...),
+		IssuedAt:     time.Now(),
+		ExpiresAt:    cert.NotAfter,
+		CertFile:     filepath.ToSlash(certFile),
+		KeyFile:      filepath.ToSlash(keyFile),
+	})
+	return
+}
+
+// find returns index of entry which has the serial number, serial number can be hex with or without colons.
+func (inv *inventory) find(serialNumber string) (idx int) {
+	sn, ok := parseSerialNumber(serialNumber)
+	if !ok {
+		return -1
+	}
+	for i, entry := range inv.Certificates {
+		if esn, valid := parseSerialNumber(entry.SerialNumber); valid && esn.Cmp(sn) == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// revoked returns entries which are revoked and issued by issuer.
+func (inv *inventory) revoked(issuer *x509.Certificate) (entries []inventoryEntry) {
+	entries = make([]inventoryEntry, 0, 1)
+	for _, entry := range inv.Certificates {
+		if entry.Revoked && entry.issuedBy(issuer) {
+			entries = append(entries, entry)
+		}
+	}
+	return
+}
+
+// issuedBy reports whether the entry was issued by issuer, names are compared by their der bytes.
+func (entry inventoryEntry) issuedBy(issuer *x509.Certificate) bool {
+	return bytes.Equal(entry.RawIssuer, issuer.RawSubject)
+}
+
+func (inv *inventory) file(dir string) (file outputFile, err error) {
+	p, encodeErr := json.MarshalIndent(inv, "", "  ")
+	if encodeErr != nil {
+		err = fmt.Errorf("fnc: encode inventory failed, %v", encodeErr)
+		return
+	}
+	file = outputFile{name: filepath.Join(dir, inventoryFilename), content: p}
+	return
+}
+
+func parseSerialNumber(s string) (sn *big.Int, ok bool) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ":", "")
+	if s == "" {
+		return
+	}
+	sn, ok = new(big.Int).SetString(s, 16)
+	return
+}
+
+// relativeFilename returns filename relative to dir, or the absolute one when it can not be relative.
+func relativeFilename(dir string, filename string) string {
+	absDir, dirErr := filepath.Abs(dir)
+	absFilename, filenameErr := filepath.Abs(filename)
+	if dirErr != nil || filenameErr != nil {
+		return filename
+	}
+	rel, relErr := filepath.Rel(absDir, absFilename)
+	if relErr != nil {
+		return absFilename
+	}
+	return rel
+}
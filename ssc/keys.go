@@ -0,0 +1,208 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ssc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+const (
+	rsaKeyType     = "rsa"
+	ecdsaKeyType   = "ecdsa"
+	ed25519KeyType = "ed25519"
+)
+
+const (
+	pkcs1KeyFormat = "pkcs1"
+	pkcs8KeyFormat = "pkcs8"
+)
+
+type keyOptions struct {
+	Type   string
+	Size   int
+	Curve  string
+	Format string
+}
+
+func (opt keyOptions) check() (err error) {
+	switch opt.Type {
+	case rsaKeyType:
+		if opt.Size < 2048 {
+			err = fmt.Errorf("fnc: key size of rsa must be at least 2048")
+			return
+		}
+		break
+	case ecdsaKeyType:
+		if _, curveErr := ellipticCurve(opt.Curve); curveErr != nil {
+			err = curveErr
+			return
+		}
+		break
+	case ed25519KeyType:
+		if opt.Format == pkcs1KeyFormat {
+			err = fmt.Errorf("fnc: ed25519 key only supports pkcs8 format")
+			return
+		}
+		break
+	default:
+		err = fmt.Errorf("fnc: key type must be rsa, ecdsa or ed25519")
+		return
+	}
+	if opt.Format != pkcs1KeyFormat && opt.Format != pkcs8KeyFormat {
+		err = fmt.Errorf("fnc: key format must be pkcs1 or pkcs8")
+		return
+	}
+	return
+}
+
+func (opt keyOptions) generate() (key crypto.Signer, err error) {
+	switch opt.Type {
+	case rsaKeyType:
+		key, err = rsa.GenerateKey(rand.Reader, opt.Size)
+		break
+	case ecdsaKeyType:
+		curve, curveErr := ellipticCurve(opt.Curve)
+		if curveErr != nil {
+			err = curveErr
+			return
+		}
+		key, err = ecdsa.GenerateKey(curve, rand.Reader)
+		break
+	case ed25519KeyType:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+		break
+	default:
+		err = fmt.Errorf("fnc: key type %s is unsupported", opt.Type)
+		return
+	}
+	if err != nil {
+		err = fmt.Errorf("fnc: generate %s key failed, %v", opt.Type, err)
+		return
+	}
+	return
+}
+
+func ellipticCurve(name string) (curve elliptic.Curve, err error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "P-256", "P256":
+		curve = elliptic.P256()
+		break
+	case "P-384", "P384":
+		curve = elliptic.P384()
+		break
+	case "P-521", "P521":
+		curve = elliptic.P521()
+		break
+	default:
+		err = fmt.Errorf("fnc: curve must be P-256, P-384 or P-521")
+		break
+	}
+	return
+}
+
+// encodePrivateKey encodes key into pem, pkcs1 means the traditional format, PKCS#1 for rsa and SEC 1 for ecdsa.
+func encodePrivateKey(key crypto.Signer, format string) (p []byte, err error) {
+	var block *pem.Block
+	if format == pkcs1KeyFormat {
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}
+			break
+		case *ecdsa.PrivateKey:
+			der, derErr := x509.MarshalECPrivateKey(k)
+			if derErr != nil {
+				err = fmt.Errorf("fnc: encode private key failed, %v", derErr)
+				return
+			}
+			block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+			break
+		default:
+			err = fmt.Errorf("fnc: encode private key failed, pkcs1 format is unsupported by %T", key)
+			return
+		}
+	} else {
+		der, derErr := x509.MarshalPKCS8PrivateKey(key)
+		if derErr != nil {
+			err = fmt.Errorf("fnc: encode private key failed, %v", derErr)
+			return
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	p = pem.EncodeToMemory(block)
+	return
+}
+
+// parsePrivateKey parses pem encoded private key, password is required when key is encrypted.
+func parsePrivateKey(p []byte, password string) (key crypto.Signer, err error) {
+	block, _ := pem.Decode(p)
+	if block == nil {
+		err = fmt.Errorf("fnc: parse private key failed, key is not pem encoded")
+		return
+	}
+	var raw interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		raw, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		break
+	case "EC PRIVATE KEY":
+		raw, err = x509.ParseECPrivateKey(block.Bytes)
+		break
+	case "PRIVATE KEY":
+		raw, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		break
+	case "ENCRYPTED PRIVATE KEY":
+		raw, err = decryptPKCS8(block.Bytes, password)
+		break
+	default:
+		err = fmt.Errorf("pem type %s is unsupported", block.Type)
+		break
+	}
+	if err != nil {
+		err = fmt.Errorf("fnc: parse private key failed, %v", err)
+		return
+	}
+	signer, ok := raw.(crypto.Signer)
+	if !ok {
+		err = fmt.Errorf("fnc: parse private key failed, %T is unsupported", raw)
+		return
+	}
+	key = signer
+	return
+}
+
+func parseCertificate(p []byte) (cert *x509.Certificate, err error) {
+	block, _ := pem.Decode(p)
+	if block == nil || block.Type != "CERTIFICATE" {
+		err = fmt.Errorf("fnc: parse certificate failed, certificate is not pem encoded")
+		return
+	}
+	cert, err = x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		err = fmt.Errorf("fnc: parse certificate failed, %v", err)
+		return
+	}
+	return
+}
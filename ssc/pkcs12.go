@@ -0,0 +1,63 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ssc
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// pkcs12 bundles are encrypted by AES-256-CBC with PBKDF2-HMAC-SHA256 and authenticated by hmac-sha256,
+// they are supported by java 12 and openssl 1.1.1 or later.
+
+// encodePKCS12 encodes key, its cert and the chain of cert into a password protected pkcs12 bundle.
+func encodePKCS12(key crypto.Signer, cert *x509.Certificate, chain []*x509.Certificate, password string) (p []byte, err error) {
+	if password == "" {
+		err = fmt.Errorf("fnc: encode pkcs12 failed, password is required")
+		return
+	}
+	p, err = pkcs12.Modern.Encode(key, cert, chain, password)
+	if err != nil {
+		err = fmt.Errorf("fnc: encode pkcs12 failed, %v", err)
+		return
+	}
+	return
+}
+
+// encodePKCS12TrustStore encodes certs into a password protected pkcs12 trust store which can be loaded by java.
+func encodePKCS12TrustStore(certs []*x509.Certificate, alias string, password string) (p []byte, err error) {
+	if password == "" {
+		err = fmt.Errorf("fnc: encode pkcs12 trust store failed, password is required")
+		return
+	}
+	entries := make([]pkcs12.TrustStoreEntry, 0, len(certs))
+	for i, cert := range certs {
+		name := alias
+		if i > 0 {
+			name = fmt.Sprintf("%s-%d", alias, i)
+		}
+		entries = append(entries, pkcs12.TrustStoreEntry{Cert: cert, FriendlyName: name})
+	}
+	p, err = pkcs12.Modern.EncodeTrustStoreEntries(entries, password)
+	if err != nil {
+		err = fmt.Errorf("fnc: encode pkcs12 trust store failed, %v", err)
+		return
+	}
+	return
+}
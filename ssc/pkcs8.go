@@ -0,0 +1,62 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ssc
+
+import (
+	"crypto"
+	"encoding/pem"
+	"fmt"
+	"github.com/youmark/pkcs8"
+)
+
+// encrypted private keys are PKCS#8 EncryptedPrivateKeyInfo of PBES2 with PBKDF2-HMAC-SHA256 and AES-256-CBC (RFC 8018),
+// they can be read by openssl and most tls libraries.
+
+var pkcs8Options = &pkcs8.Opts{
+	Cipher: pkcs8.AES256CBC,
+	KDFOpts: pkcs8.PBKDF2Opts{
+		SaltSize:       16,
+		IterationCount: 100000,
+		HMACHash:       crypto.SHA256,
+	},
+}
+
+// encryptPrivateKey encrypts a pem encoded private key into an ENCRYPTED PRIVATE KEY pem block.
+func encryptPrivateKey(p []byte, password string) (encrypted []byte, err error) {
+	key, parseErr := parsePrivateKey(p, "")
+	if parseErr != nil {
+		err = parseErr
+		return
+	}
+	der, derErr := pkcs8.MarshalPrivateKey(key, []byte(password), pkcs8Options)
+	if derErr != nil {
+		err = fmt.Errorf("fnc: encrypt private key failed, %v", derErr)
+		return
+	}
+	encrypted = pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der})
+	return
+}
+
+// decryptPKCS8 decrypts der of EncryptedPrivateKeyInfo into a private key.
+func decryptPKCS8(der []byte, password string) (key interface{}, err error) {
+	if password == "" {
+		err = fmt.Errorf("key is encrypted, passphrase is required")
+		return
+	}
+	key, _, err = pkcs8.ParsePrivateKey(der, []byte(password))
+	return
+}
@@ -0,0 +1,274 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ssc
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/urfave/cli/v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var renewCommand = &cli.Command{
+	Name:        "renew",
+	Aliases:     nil,
+	Usage:       "fnc ssc renew --ca ca.crt --ca-key ca.key server.crt",
+	Description: "re-issue a certificate with a new key, subject and alternative names are kept, its fullchain file is re-written as well",
+	ArgsUsage:   "",
+	Category:    "",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Required: false,
+			Name:     "ca",
+			Value:    "",
+			Usage:    "ca cert file, default is ca.crt in the dir of renewed certificate",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "ca-key",
+			Value:    "",
+			Usage:    "ca key file, default is ca.key in the dir of renewed certificate",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "key",
+			Value:    "",
+			Usage:    "key file of renewed certificate, default is the cert file with .key extension",
+		},
+		&cli.IntFlag{
+			Required: false,
+			Name:     "days",
+			Value:    0,
+			Usage:    "validity days, default is the validity of renewed certificate",
+		},
+		keyPassFlag(),
+	},
+	Action: func(ctx *cli.Context) (err error) {
+		crtFilename := strings.TrimSpace(ctx.Args().First())
+		if crtFilename == "" {
+			err = errors.Warning("fnc: renew ssc failed").WithCause(errors.Warning("file is undefined"))
+			return
+		}
+		dir := filepath.Dir(crtFilename)
+		caFilename := strings.TrimSpace(ctx.String("ca"))
+		if caFilename == "" {
+			caFilename = filepath.Join(dir, "ca.crt")
+		}
+		caKeyFilename := strings.TrimSpace(ctx.String("ca-key"))
+		if caKeyFilename == "" {
+			caKeyFilename = filepath.Join(dir, "ca.key")
+		}
+		keyFilename := strings.TrimSpace(ctx.String("key"))
+		if keyFilename == "" {
+			keyFilename = strings.TrimSuffix(crtFilename, filepath.Ext(crtFilename)) + ".key"
+		}
+		days := ctx.Int("days")
+		if days < 0 {
+			err = errors.Warning("fnc: renew ssc failed").WithCause(errors.Warning("days is invalid")).WithMeta("days", ctx.String("days"))
+			return
+		}
+		err = renew(crtFilename, keyFilename, caFilename, caKeyFilename, ctx.String("key-pass"), days)
+		if err != nil {
+			err = errors.Warning("fnc: renew ssc failed").WithCause(err).WithMeta("file", crtFilename)
+			return
+		}
+		return
+	},
+}
+
+func renew(crtFilename string, keyFilename string, caFilename string, caKeyFilename string, caKeyPass string, days int) (err error) {
+	certs, readErr := readCertificates(crtFilename)
+	if readErr != nil {
+		err = readErr
+		return
+	}
+	cert := certs[0]
+	if cert.IsCA {
+		err = fmt.Errorf("fnc: %s is a ca certificate, only leaf certificates can be renewed", crtFilename)
+		return
+	}
+	ca, caKey, loadErr := loadCA(caFilename, caKeyFilename, caKeyPass)
+	if loadErr != nil {
+		err = loadErr
+		return
+	}
+	caCert, _ := parseCertificate(ca)
+	if signatureErr := cert.CheckSignatureFrom(caCert); signatureErr != nil {
+		err = fmt.Errorf("fnc: %s was not issued by %s, %v", crtFilename, caFilename, signatureErr)
+		return
+	}
+	if days == 0 {
+		days = validityDays(cert)
+	}
+	// usages of renewed certificate are kept
+	crt, key, createErr := create(subjectOf(cert), alternativeNamesOf(cert), days, false, cert.ExtKeyUsage, keyOptionsOf(cert, keyFilename), ca, caKey)
+	if createErr != nil {
+		err = createErr
+		return
+	}
+	caDir := filepath.Dir(caFilename)
+	inv, invErr := loadInventory(caDir)
+	if invErr != nil {
+		err = invErr
+		return
+	}
+	usage := serverUsage
+	if len(cert.ExtKeyUsage) == 1 && cert.ExtKeyUsage[0] == x509.ExtKeyUsageClientAuth {
+		usage = clientUsage
+	}
+	if idx := inv.find(colonHex(cert.SerialNumber.Bytes())); idx > -1 {
+		usage = inv.Certificates[idx].Usage
+	}
+	err = inv.add(crt, usage, relativeFilename(caDir, crtFilename), relativeFilename(caDir, keyFilename))
+	if err != nil {
+		return
+	}
+	invFile, invFileErr := inv.file(caDir)
+	if invFileErr != nil {
+		err = invFileErr
+		return
+	}
+	files := []outputFile{{name: crtFilename, content: crt}, {name: keyFilename, content: key}, invFile}
+	// fullchain of renewed certificate keeps its intermediates
+	fullchain, hasFullchain, fullchainErr := renewFullchain(fullchainFilenameOf(crtFilename), cert, crt)
+	if fullchainErr != nil {
+		err = fullchainErr
+		return
+	}
+	if hasFullchain {
+		files = append(files, fullchain)
+	}
+	err = writeFiles("", files, true)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// fullchainFilenameOf returns the fullchain file which is written with the cert by generating, e.g. fullchain.pem of server.crt and client-fullchain.pem of client.crt.
+func fullchainFilenameOf(crtFilename string) string {
+	name := strings.TrimSuffix(filepath.Base(crtFilename), filepath.Ext(crtFilename))
+	if name == serverUsage {
+		return filepath.Join(filepath.Dir(crtFilename), "fullchain.pem")
+	}
+	return filepath.Join(filepath.Dir(crtFilename), name+"-fullchain.pem")
+}
+
+// renewFullchain replaces the leaf of the fullchain file by crt, ok is false when the file does not exist or its leaf is not the renewed one.
+func renewFullchain(filename string, renewed *x509.Certificate, crt []byte) (file outputFile, ok bool, err error) {
+	p, readErr := ioutil.ReadFile(filename)
+	if readErr != nil {
+		if os.IsNotExist(readErr) {
+			return
+		}
+		err = fmt.Errorf("fnc: read %s failed, %v", filename, readErr)
+		return
+	}
+	certs, decodeErr := decodeCertificates(p)
+	if decodeErr != nil {
+		err = fmt.Errorf("fnc: decode %s failed, %v", filename, decodeErr)
+		return
+	}
+	if len(certs) == 0 || !bytes.Equal(certs[0].Raw, renewed.Raw) {
+		return
+	}
+	content := crt
+	for _, cert := range certs[1:] {
+		content = concat(content, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	}
+	file = outputFile{name: filename, content: content}
+	ok = true
+	return
+}
+
+func subjectOf(cert *x509.Certificate) (sub subject) {
+	first := func(items []string) string {
+		if len(items) == 0 {
+			return ""
+		}
+		return items[0]
+	}
+	sub = subject{
+		Country:            first(cert.Subject.Country),
+		Province:           first(cert.Subject.Province),
+		City:               first(cert.Subject.Locality),
+		Organization:       first(cert.Subject.Organization),
+		OrganizationalUnit: first(cert.Subject.OrganizationalUnit),
+		CommonName:         cert.Subject.CommonName,
+	}
+	return
+}
+
+func alternativeNamesOf(cert *x509.Certificate) (names alternativeNames) {
+	names = alternativeNames{
+		IPs:      make([]string, 0, len(cert.IPAddresses)),
+		Emails:   cert.EmailAddresses,
+		DNSNames: cert.DNSNames,
+	}
+	for _, ip := range cert.IPAddresses {
+		names.IPs = append(names.IPs, ip.String())
+	}
+	return
+}
+
+// keyOptionsOf returns key options which have same algorithm of cert and same format of key file.
+func keyOptionsOf(cert *x509.Certificate, keyFilename string) (opt keyOptions) {
+	opt = keyOptions{
+		Type:   rsaKeyType,
+		Size:   4096,
+		Curve:  "P-256",
+		Format: pkcs1KeyFormat,
+	}
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		opt.Size = pub.N.BitLen()
+		break
+	case *ecdsa.PublicKey:
+		opt.Type = ecdsaKeyType
+		opt.Curve = pub.Curve.Params().Name
+		break
+	case ed25519.PublicKey:
+		opt.Type = ed25519KeyType
+		opt.Format = pkcs8KeyFormat
+		break
+	}
+	if p, readErr := ioutil.ReadFile(keyFilename); readErr == nil {
+		if block, _ := pem.Decode(p); block != nil && block.Type == "PRIVATE KEY" {
+			opt.Format = pkcs8KeyFormat
+		}
+	}
+	return
+}
+
+// validityDays returns validity days of cert, the one day before issuing which is added by create is excluded.
+func validityDays(cert *x509.Certificate) (days int) {
+	days = int(cert.NotAfter.Sub(cert.NotBefore)/(24*time.Hour)) - 1
+	if days < 1 {
+		days = 1
+	}
+	return
+}
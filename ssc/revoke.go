@@ -0,0 +1,264 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ssc
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/urfave/cli/v2"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var listCommand = &cli.Command{
+	Name:        "list",
+	Aliases:     nil,
+	Usage:       "fnc ssc list .",
+	Description: "list certificates in index.json of ca dir",
+	ArgsUsage:   "",
+	Category:    "",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Required: false,
+			Name:     "json",
+			Value:    false,
+			Usage:    "print as json",
+		},
+	},
+	Action: func(ctx *cli.Context) (err error) {
+		dir := strings.TrimSpace(ctx.Args().First())
+		if dir == "" {
+			dir = "."
+		}
+		inv, invErr := loadInventory(dir)
+		if invErr != nil {
+			err = errors.Warning("fnc: list ssc failed").WithCause(invErr).WithMeta("dir", dir)
+			return
+		}
+		if ctx.Bool("json") {
+			p, encodeErr := json.MarshalIndent(inv.Certificates, "", "  ")
+			if encodeErr != nil {
+				err = errors.Warning("fnc: list ssc failed").WithCause(encodeErr).WithMeta("dir", dir)
+				return
+			}
+			fmt.Println(string(p))
+			return
+		}
+		now := time.Now()
+		for _, entry := range inv.Certificates {
+			state := "valid"
+			if entry.Revoked {
+				state = "revoked"
+			} else if now.After(entry.ExpiresAt) {
+				state = "expired"
+			}
+			fmt.Println(fmt.Sprintf("%s %-12s %-7s %s %s %s", entry.SerialNumber, entry.Usage, state, entry.ExpiresAt.Format(time.RFC3339), entry.Subject, entry.CertFile))
+		}
+		return
+	},
+}
+
+// revocationReasons are reason codes of RFC 5280 section 5.3.1.
+var revocationReasons = map[string]int{
+	"unspecified":          0,
+	"keyCompromise":        1,
+	"cACompromise":         2,
+	"affiliationChanged":   3,
+	"superseded":           4,
+	"cessationOfOperation": 5,
+}
+
+var oidReasonCode = asn1.ObjectIdentifier{2, 5, 29, 21}
+
+var revokeCommand = &cli.Command{
+	Name:        "revoke",
+	Aliases:     nil,
+	Usage:       "fnc ssc revoke --dir . {serial number}",
+	Description: "revoke a certificate in index.json and write a crl signed by its ca",
+	ArgsUsage:   "",
+	Category:    "",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Required: false,
+			Name:     "dir",
+			Value:    ".",
+			Usage:    "dir of index.json",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "ca",
+			Value:    "",
+			Usage:    "issuer cert file of revoked certificate, default is ca.crt in dir",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "ca-key",
+			Value:    "",
+			Usage:    "issuer key file of revoked certificate, default is ca.key in dir",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "reason",
+			Value:    "unspecified",
+			Usage:    "revocation reason, unspecified, keyCompromise, cACompromise, affiliationChanged, superseded or cessationOfOperation",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "crl",
+			Value:    "",
+			Usage:    "crl file, default is {issuer}.crl.pem in dir, e.g. ca.crl.pem for ca.crt",
+		},
+		&cli.IntFlag{
+			Required: false,
+			Name:     "crl-days",
+			Value:    30,
+			Usage:    "days until next crl update",
+		},
+		keyPassFlag(),
+	},
+	Action: func(ctx *cli.Context) (err error) {
+		serialNumber := strings.TrimSpace(ctx.Args().First())
+		if _, ok := parseSerialNumber(serialNumber); !ok {
+			err = errors.Warning("fnc: revoke ssc failed").WithCause(errors.Warning("serial number is invalid")).WithMeta("serial", serialNumber)
+			return
+		}
+		reason, hasReason := revocationReasons[strings.TrimSpace(ctx.String("reason"))]
+		if !hasReason {
+			err = errors.Warning("fnc: revoke ssc failed").WithCause(errors.Warning("reason is invalid")).WithMeta("reason", ctx.String("reason"))
+			return
+		}
+		crlDays := ctx.Int("crl-days")
+		if crlDays < 1 {
+			err = errors.Warning("fnc: revoke ssc failed").WithCause(errors.Warning("crl-days is invalid")).WithMeta("crl-days", ctx.String("crl-days"))
+			return
+		}
+		dir := strings.TrimSpace(ctx.String("dir"))
+		caFilename := strings.TrimSpace(ctx.String("ca"))
+		if caFilename == "" {
+			caFilename = filepath.Join(dir, "ca.crt")
+		}
+		caKeyFilename := strings.TrimSpace(ctx.String("ca-key"))
+		if caKeyFilename == "" {
+			caKeyFilename = filepath.Join(dir, "ca.key")
+		}
+		crlFilename := strings.TrimSpace(ctx.String("crl"))
+		if crlFilename == "" {
+			crlFilename = filepath.Join(dir, crlFilenameOf(caFilename))
+		}
+		err = revoke(dir, serialNumber, strings.TrimSpace(ctx.String("reason")), reason, caFilename, caKeyFilename, ctx.String("key-pass"), crlFilename, crlDays)
+		if err != nil {
+			err = errors.Warning("fnc: revoke ssc failed").WithCause(err).WithMeta("serial", serialNumber)
+			return
+		}
+		return
+	},
+}
+
+func revoke(dir string, serialNumber string, reasonName string, reason int, caFilename string, caKeyFilename string, caKeyPass string, crlFilename string, crlDays int) (err error) {
+	inv, invErr := loadInventory(dir)
+	if invErr != nil {
+		err = invErr
+		return
+	}
+	idx := inv.find(serialNumber)
+	if idx < 0 {
+		err = fmt.Errorf("fnc: %s was not found in %s", serialNumber, filepath.Join(dir, inventoryFilename))
+		return
+	}
+	ca, caKey, loadErr := loadCA(caFilename, caKeyFilename, caKeyPass)
+	if loadErr != nil {
+		err = loadErr
+		return
+	}
+	caCert, _ := parseCertificate(ca)
+	key, _ := parsePrivateKey(caKey, "")
+	entry := inv.Certificates[idx]
+	if !entry.issuedBy(caCert) {
+		err = fmt.Errorf("fnc: %s was issued by %s, use --ca and --ca-key to set its issuer", serialNumber, entry.Issuer)
+		return
+	}
+	now := time.Now()
+	if !entry.Revoked {
+		entry.Revoked = true
+		entry.RevokedAt = &now
+		entry.RevocationReason = reasonName
+		inv.Certificates[idx] = entry
+	}
+	inv.CRLNumber++
+	// crl
+	revoked := inv.revoked(caCert)
+	revokedCertificates := make([]pkix.RevokedCertificate, 0, len(revoked))
+	for _, item := range revoked {
+		sn, _ := parseSerialNumber(item.SerialNumber)
+		revocationTime := now
+		if item.RevokedAt != nil {
+			revocationTime = *item.RevokedAt
+		}
+		revokedCertificate := pkix.RevokedCertificate{
+			SerialNumber:   sn,
+			RevocationTime: revocationTime,
+		}
+		// reason code extension is absent when reason is unspecified, see RFC 5280 section 5.3.1
+		if reason := revocationReasons[item.RevocationReason]; reason != 0 {
+			code, encodeErr := asn1.Marshal(asn1.Enumerated(reason))
+			if encodeErr != nil {
+				err = fmt.Errorf("fnc: encode revocation reason failed, %v", encodeErr)
+				return
+			}
+			revokedCertificate.Extensions = []pkix.Extension{{Id: oidReasonCode, Value: code}}
+		}
+		revokedCertificates = append(revokedCertificates, revokedCertificate)
+	}
+	crl, crlErr := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		RevokedCertificates: revokedCertificates,
+		Number:              big.NewInt(inv.CRLNumber),
+		ThisUpdate:          now,
+		NextUpdate:          now.Add(time.Duration(crlDays) * 24 * time.Hour),
+	}, caCert, key)
+	if crlErr != nil {
+		err = fmt.Errorf("fnc: create crl failed, %v", crlErr)
+		return
+	}
+	invFile, invFileErr := inv.file(dir)
+	if invFileErr != nil {
+		err = invFileErr
+		return
+	}
+	err = writeFiles("", []outputFile{
+		invFile,
+		{name: crlFilename, content: pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl})},
+	}, true)
+	if err != nil {
+		return
+	}
+	fmt.Println(fmt.Sprintf("fnc: %s has been revoked, %d certificates are in %s", entry.SerialNumber, len(revokedCertificates), crlFilename))
+	return
+}
+
+// crlFilenameOf returns the crl filename of issuer, each issuer has its own crl.
+func crlFilenameOf(caFilename string) string {
+	name := filepath.Base(caFilename)
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ".crl.pem"
+}
@@ -0,0 +1,214 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ssc
+
+import (
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/urfave/cli/v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var signCommand = &cli.Command{
+	Name:        "sign",
+	Aliases:     nil,
+	Usage:       "fnc ssc sign --name=orders --usage=server .",
+	Description: "issue a server or client certificate from an existing ca",
+	ArgsUsage:   "",
+	Category:    "",
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Required: true,
+			Name:     "name",
+			Value:    "",
+			Usage:    "certificate name, files will be named as {name}-{usage}.crt and {name}-{usage}.key",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "usage",
+			Value:    "server",
+			Usage:    "certificate usage, server or client",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "ca",
+			Value:    "",
+			Usage:    "ca cert file, default is ca.crt in output dir",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "ca-key",
+			Value:    "",
+			Usage:    "ca key file, default is ca.key in output dir",
+		},
+		&cli.BoolFlag{
+			Required: false,
+			Name:     "force",
+			Value:    false,
+			Usage:    "overwrite existing files",
+		},
+		keyPassFlag(),
+	}, certificateFlags()...),
+	Action: func(ctx *cli.Context) (err error) {
+		outputDir := strings.TrimSpace(ctx.Args().First())
+		if outputDir == "" {
+			err = errors.Warning("fnc: sign ssc failed").WithCause(errors.Warning("output is undefined"))
+			return
+		}
+		name := strings.TrimSpace(ctx.String("name"))
+		if name == "" || strings.ContainsAny(name, `/\`) {
+			err = errors.Warning("fnc: sign ssc failed").WithCause(errors.Warning("name is invalid")).WithMeta("name", name)
+			return
+		}
+		usage := strings.TrimSpace(strings.ToLower(ctx.String("usage")))
+		if usage != "server" && usage != "client" {
+			err = errors.Warning("fnc: sign ssc failed").WithCause(errors.Warning("usage is invalid")).WithMeta("usage", usage)
+			return
+		}
+		cn := ctx.String("cn")
+		if cn == "" {
+			cn = name
+		}
+		values, flagsErr := certificateFlagValues(ctx, cn)
+		if flagsErr != nil {
+			err = errors.Warning("fnc: sign ssc failed").WithCause(flagsErr)
+			return
+		}
+		caFilename := strings.TrimSpace(ctx.String("ca"))
+		if caFilename == "" {
+			caFilename = filepath.Join(outputDir, "ca.crt")
+		}
+		caKeyFilename := strings.TrimSpace(ctx.String("ca-key"))
+		if caKeyFilename == "" {
+			caKeyFilename = filepath.Join(outputDir, "ca.key")
+		}
+		err = sign(signOptions{
+			Name:          name,
+			Usage:         usage,
+			Subject:       values.Subject,
+			Names:         values.Names,
+			Days:          values.Days,
+			Key:           values.Key,
+			CAFilename:    caFilename,
+			CAKeyFilename: caKeyFilename,
+			CAKeyPass:     ctx.String("key-pass"),
+			Force:         ctx.Bool("force"),
+		}, outputDir)
+		if err != nil {
+			err = errors.Warning("fnc: sign ssc failed").WithCause(err).WithMeta("name", name)
+			return
+		}
+		return
+	},
+}
+
+type signOptions struct {
+	Name          string
+	Usage         string
+	Subject       subject
+	Names         alternativeNames
+	Days          int
+	Key           keyOptions
+	CAFilename    string
+	CAKeyFilename string
+	CAKeyPass     string
+	Force         bool
+}
+
+func sign(opt signOptions, outputDir string) (err error) {
+	ca, caKey, loadErr := loadCA(opt.CAFilename, opt.CAKeyFilename, opt.CAKeyPass)
+	if loadErr != nil {
+		err = loadErr
+		return
+	}
+	crt, key, createErr := create(opt.Subject, opt.Names, opt.Days, false, extKeyUsagesOf(opt.Usage), opt.Key, ca, caKey)
+	if createErr != nil {
+		err = createErr
+		return
+	}
+	crtFilename := filepath.Join(outputDir, fmt.Sprintf("%s-%s.crt", opt.Name, opt.Usage))
+	keyFilename := filepath.Join(outputDir, fmt.Sprintf("%s-%s.key", opt.Name, opt.Usage))
+	if !opt.Force {
+		for _, filename := range []string{crtFilename, keyFilename} {
+			if _, statErr := os.Stat(filename); statErr == nil {
+				err = fmt.Errorf("fnc: %s is exist, use --force to overwrite it", filename)
+				return
+			}
+		}
+	}
+	// inventory is next to the ca
+	caDir := filepath.Dir(opt.CAFilename)
+	inv, invErr := loadInventory(caDir)
+	if invErr != nil {
+		err = invErr
+		return
+	}
+	err = inv.add(crt, opt.Usage, relativeFilename(caDir, crtFilename), relativeFilename(caDir, keyFilename))
+	if err != nil {
+		return
+	}
+	invFile, invFileErr := inv.file(caDir)
+	if invFileErr != nil {
+		err = invFileErr
+		return
+	}
+	err = writeFiles("", []outputFile{
+		{name: crtFilename, content: crt},
+		{name: keyFilename, content: key},
+		invFile,
+	}, true)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// loadCA reads ca and its key, the returned key is decrypted when it is encrypted.
+func loadCA(caFilename string, caKeyFilename string, password string) (ca []byte, caKey []byte, err error) {
+	ca, err = ioutil.ReadFile(caFilename)
+	if err != nil {
+		err = fmt.Errorf("fnc: read ca failed, %v", err)
+		return
+	}
+	cert, parseErr := parseCertificate(ca)
+	if parseErr != nil {
+		err = parseErr
+		return
+	}
+	if !cert.IsCA {
+		err = fmt.Errorf("fnc: %s is not a ca certificate", caFilename)
+		return
+	}
+	caKey, err = ioutil.ReadFile(caKeyFilename)
+	if err != nil {
+		err = fmt.Errorf("fnc: read ca key failed, %v", err)
+		return
+	}
+	key, keyErr := parsePrivateKey(caKey, password)
+	if keyErr != nil {
+		err = keyErr
+		return
+	}
+	caKey, err = encodePrivateKey(key, pkcs8KeyFormat)
+	if err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright 2021 Wang Min Xiang
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ssc
+
+import (
+	"crypto/x509"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/urfave/cli/v2"
+	"strings"
+	"time"
+)
+
+var verifyCommand = &cli.Command{
+	Name:        "verify",
+	Aliases:     nil,
+	Usage:       "fnc ssc verify --ca ca.crt --host api.example.com server.crt",
+	Description: "verify chain, expiry, key usage and hostname of a certificate, exit with non-zero when failed",
+	ArgsUsage:   "",
+	Category:    "",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Required: true,
+			Name:     "ca",
+			Value:    "",
+			Usage:    "trusted root ca file",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "chain",
+			Value:    "",
+			Usage:    "intermediate cas file, certificates after the first one in verified file are used too",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "host",
+			Value:    "",
+			Usage:    "dns name or ip which the certificate must be valid for",
+		},
+		&cli.StringFlag{
+			Required: false,
+			Name:     "usage",
+			Value:    "server",
+			Usage:    "expected usage, server, client or any",
+		},
+	},
+	Action: func(ctx *cli.Context) (err error) {
+		filename := strings.TrimSpace(ctx.Args().First())
+		if filename == "" {
+			err = errors.Warning("fnc: verify ssc failed").WithCause(errors.Warning("file is undefined"))
+			return
+		}
+		var usage x509.ExtKeyUsage
+		switch strings.TrimSpace(strings.ToLower(ctx.String("usage"))) {
+		case "server":
+			usage = x509.ExtKeyUsageServerAuth
+			break
+		case "client":
+			usage = x509.ExtKeyUsageClientAuth
+			break
+		case "any":
+			usage = x509.ExtKeyUsageAny
+			break
+		default:
+			err = errors.Warning("fnc: verify ssc failed").WithCause(errors.Warning("usage is invalid")).WithMeta("usage", ctx.String("usage"))
+			return
+		}
+		certs, readErr := readCertificates(filename)
+		if readErr != nil {
+			err = errors.Warning("fnc: verify ssc failed").WithCause(readErr).WithMeta("file", filename)
+			return
+		}
+		roots, rootsErr := readCertificates(ctx.String("ca"))
+		if rootsErr != nil {
+			err = errors.Warning("fnc: verify ssc failed").WithCause(rootsErr).WithMeta("ca", ctx.String("ca"))
+			return
+		}
+		intermediates := certs[1:]
+		if chainFilename := strings.TrimSpace(ctx.String("chain")); chainFilename != "" {
+			chain, chainErr := readCertificates(chainFilename)
+			if chainErr != nil {
+				err = errors.Warning("fnc: verify ssc failed").WithCause(chainErr).WithMeta("chain", chainFilename)
+				return
+			}
+			intermediates = append(intermediates, chain...)
+		}
+		failures := verify(certs[0], roots, intermediates, usage, strings.TrimSpace(ctx.String("host")), time.Now())
+		if failures > 0 {
+			err = cli.Exit(fmt.Sprintf("fnc: %s failed %d checks", filename, failures), 1)
+			return
+		}
+		return
+	},
+}
+
+// verify prints result of each check and returns the number of failed checks.
+func verify(leaf *x509.Certificate, roots []*x509.Certificate, intermediates []*x509.Certificate, usage x509.ExtKeyUsage, host string, now time.Time) (failures int) {
+	report := func(name string, checkErr error) {
+		if checkErr != nil {
+			failures++
+			fmt.Println(fmt.Sprintf("%-10s failed, %v", name+":", checkErr))
+			return
+		}
+		fmt.Println(fmt.Sprintf("%-10s ok", name+":"))
+	}
+	// expiry
+	var expiryErr error
+	if now.Before(leaf.NotBefore) {
+		expiryErr = fmt.Errorf("certificate is not valid before %s", leaf.NotBefore.Format(time.RFC3339))
+	} else if now.After(leaf.NotAfter) {
+		expiryErr = fmt.Errorf("certificate expired at %s", leaf.NotAfter.Format(time.RFC3339))
+	}
+	report("expiry", expiryErr)
+	// chain
+	rootPool := x509.NewCertPool()
+	for _, root := range roots {
+		rootPool.AddCert(root)
+	}
+	intermediatePool := x509.NewCertPool()
+	for _, intermediate := range intermediates {
+		intermediatePool.AddCert(intermediate)
+	}
+	_, chainErr := leaf.Verify(x509.VerifyOptions{
+		Roots:         rootPool,
+		Intermediates: intermediatePool,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	report("chain", chainErr)
+	// key usage
+	var usageErr error
+	if leaf.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+		usageErr = fmt.Errorf("digital signature key usage is missing")
+	} else if usage != x509.ExtKeyUsageAny {
+		matched := len(leaf.ExtKeyUsage) == 0
+		for _, u := range leaf.ExtKeyUsage {
+			if u == usage || u == x509.ExtKeyUsageAny {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			usageErr = fmt.Errorf("%s ext key usage is missing", strings.Join(extKeyUsages([]x509.ExtKeyUsage{usage}), ""))
+		}
+	}
+	report("usage", usageErr)
+	// hostname
+	if host != "" {
+		report("hostname", leaf.VerifyHostname(host))
+	}
+	return
+}